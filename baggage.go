@@ -0,0 +1,197 @@
+package netcontext
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// A Format determines how context values are written to HTTP headers and gRPC
+// metadata.
+type Format int
+
+const (
+	// HeaderFormat writes one header (or metadata key) per Entry, using the
+	// configured prefix. This is the default.
+	HeaderFormat Format = iota
+	// BaggageFormat packs all entries into a single W3C 'baggage' header,
+	// using the string keys of the entries as member keys. Values are
+	// percent-encoded, as the specification prescribes, rather than
+	// base64url-encoded. The deadline is still propagated in its own header.
+	BaggageFormat
+)
+
+// BaggageHeader is the name of the W3C baggage header.
+const BaggageHeader = "baggage"
+
+// Limits from the W3C baggage specification.
+const (
+	MaxBaggageMembers = 64
+	MaxBaggageBytes   = 8192
+)
+
+// A BaggageMember is a list-member of a W3C baggage header. The value is
+// stored unescaped; properties are kept in their encoded form.
+type BaggageMember struct {
+	Key        string
+	Value      string
+	Properties []string
+}
+
+func (m BaggageMember) String() string {
+	var sb strings.Builder
+	sb.WriteString(m.Key)
+	sb.WriteByte('=')
+	sb.WriteString(escapeBaggage(m.Value))
+	for _, p := range m.Properties {
+		sb.WriteByte(';')
+		sb.WriteString(p)
+	}
+	return sb.String()
+}
+
+// Baggage is a parsed W3C baggage header.
+type Baggage []BaggageMember
+
+// ParseBaggage parses the value of a baggage header. Multiple header values
+// should be joined with commas first. An empty string yields empty Baggage.
+// Invalid members are skipped and returned as an error, along with the valid
+// members.
+func ParseBaggage(s string) (Baggage, error) {
+	var b Baggage
+	var errs []error
+	for _, raw := range strings.Split(s, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		m, err := parseBaggageMember(raw)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		b = append(b, m)
+	}
+	return b, errors.Join(errs...)
+}
+
+func parseBaggageMember(raw string) (BaggageMember, error) {
+	parts := strings.Split(raw, ";")
+	k, v, ok := strings.Cut(parts[0], "=")
+	if !ok {
+		return BaggageMember{}, fmt.Errorf("baggage member without value: %q", raw)
+	}
+	k, v = strings.TrimSpace(k), strings.TrimSpace(v)
+	if !isToken(k) {
+		return BaggageMember{}, fmt.Errorf("invalid baggage key: %q", k)
+	}
+	uv, err := url.PathUnescape(v)
+	if err != nil {
+		return BaggageMember{}, fmt.Errorf("invalid baggage value for %q: %w", k, err)
+	}
+	m := BaggageMember{Key: k, Value: uv}
+	for _, p := range parts[1:] {
+		p = strings.TrimSpace(p)
+		pk, _, _ := strings.Cut(p, "=")
+		if !isToken(strings.TrimSpace(pk)) {
+			return BaggageMember{}, fmt.Errorf("invalid baggage property: %q", p)
+		}
+		m.Properties = append(m.Properties, p)
+	}
+	return m, nil
+}
+
+// Get returns the value of the first member with the given key.
+func (b Baggage) Get(key string) (string, bool) {
+	for _, m := range b {
+		if m.Key == key {
+			return m.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set sets the value of the member with the given key, removing its
+// properties. The member is appended if absent.
+func (b *Baggage) Set(key, value string) {
+	for i := range *b {
+		if (*b)[i].Key == key {
+			(*b)[i] = BaggageMember{Key: key, Value: value}
+			return
+		}
+	}
+	*b = append(*b, BaggageMember{Key: key, Value: value})
+}
+
+// String encodes the Baggage as a header value. Members that would exceed the
+// limits of MaxBaggageMembers or MaxBaggageBytes are dropped; use Encode to
+// find out which.
+func (b Baggage) String() string {
+	s, _ := b.Encode()
+	return s
+}
+
+// Encode encodes the Baggage as a header value. Members that would exceed the
+// limits of MaxBaggageMembers or MaxBaggageBytes are dropped, their keys are
+// returned.
+func (b Baggage) Encode() (string, []string) {
+	var sb strings.Builder
+	var dropped []string
+	n := 0
+	for _, m := range b {
+		s := m.String()
+		if sb.Len() > 0 {
+			s = "," + s
+		}
+		if n == MaxBaggageMembers || sb.Len()+len(s) > MaxBaggageBytes {
+			dropped = append(dropped, m.Key)
+			continue
+		}
+		sb.WriteString(s)
+		n++
+	}
+	return sb.String(), dropped
+}
+
+// escapeBaggage percent-encodes all bytes that are not allowed in a baggage
+// value.
+func escapeBaggage(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isBaggageOctet(c) {
+			sb.WriteByte(c)
+		} else {
+			_, _ = fmt.Fprintf(&sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+// isBaggageOctet reports whether c is allowed unescaped in a baggage value.
+// The percent sign is allowed by the specification, but is escaped here.
+func isBaggageOctet(c byte) bool {
+	return c == 0x21 ||
+		(0x23 <= c && c <= 0x2B && c != '%') ||
+		(0x2D <= c && c <= 0x3A) ||
+		(0x3C <= c && c <= 0x5B) ||
+		(0x5D <= c && c <= 0x7E)
+}
+
+// isToken reports whether s is a valid RFC 7230 token.
+func isToken(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		case strings.IndexByte("!#$%&'*+-.^_`|~", c) >= 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
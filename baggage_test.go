@@ -0,0 +1,229 @@
+package netcontext_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/HayoVanLoon/go-netcontext"
+)
+
+func TestParseBaggage(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		in      string
+		want    netcontext.Baggage
+		wantErr bool
+	}{
+		{name: "empty", in: ""},
+		{name: "blank members", in: " , ,"},
+		{
+			name: "members",
+			in:   "a=1,b=2",
+			want: netcontext.Baggage{{Key: "a", Value: "1"}, {Key: "b", Value: "2"}},
+		},
+		{
+			name: "whitespace",
+			in:   " a = 1 ,\tb=2 ",
+			want: netcontext.Baggage{{Key: "a", Value: "1"}, {Key: "b", Value: "2"}},
+		},
+		{
+			name: "percent-encoded value",
+			in:   "city=Z%C3%BCrich,q=a%2Cb%3Bc%25",
+			want: netcontext.Baggage{{Key: "city", Value: "Zürich"}, {Key: "q", Value: "a,b;c%"}},
+		},
+		{
+			name: "empty value",
+			in:   "a=",
+			want: netcontext.Baggage{{Key: "a", Value: ""}},
+		},
+		{
+			name: "properties",
+			in:   "a=1;p1;p2=x , b=2",
+			want: netcontext.Baggage{{Key: "a", Value: "1", Properties: []string{"p1", "p2=x"}}, {Key: "b", Value: "2"}},
+		},
+		{
+			name:    "member without value",
+			in:      "a=1,bad,b=2",
+			want:    netcontext.Baggage{{Key: "a", Value: "1"}, {Key: "b", Value: "2"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid key",
+			in:      "a b=1,c=3",
+			want:    netcontext.Baggage{{Key: "c", Value: "3"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid escape",
+			in:      "a=%zz,c=3",
+			want:    netcontext.Baggage{{Key: "c", Value: "3"}},
+			wantErr: true,
+		},
+		{
+			name:    "invalid property",
+			in:      "a=1;p q,c=3",
+			want:    netcontext.Baggage{{Key: "c", Value: "3"}},
+			wantErr: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := netcontext.ParseBaggage(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("expected %#v, got %#v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestBaggage_roundTrip(t *testing.T) {
+	for _, v := range []string{"", "plain", "Zürich", "a,b;c=d", "100%", `"quoted" \back`, " spaced ", "b64:YQ"} {
+		t.Run(v, func(t *testing.T) {
+			b := netcontext.Baggage{{Key: "k", Value: v, Properties: []string{"p=1"}}}
+			s := b.String()
+			if strings.ContainsAny(s, " \",\\") {
+				t.Errorf("expected %q to be escaped", s)
+			}
+			got, err := netcontext.ParseBaggage(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, b) {
+				t.Errorf("expected %#v, got %#v", b, got)
+			}
+		})
+	}
+}
+
+func TestBaggage_Encode(t *testing.T) {
+	t.Run("member limit", func(t *testing.T) {
+		var b netcontext.Baggage
+		for i := range netcontext.MaxBaggageMembers + 2 {
+			b.Set(fmt.Sprintf("m%d", i), "v")
+		}
+		s, dropped := b.Encode()
+		if want := []string{"m64", "m65"}; !slices.Equal(dropped, want) {
+			t.Errorf("expected dropped %v, got %v", want, dropped)
+		}
+		if n := strings.Count(s, ",") + 1; n != netcontext.MaxBaggageMembers {
+			t.Errorf("expected %d members, got %d", netcontext.MaxBaggageMembers, n)
+		}
+	})
+	t.Run("byte limit", func(t *testing.T) {
+		big := strings.Repeat("x", netcontext.MaxBaggageBytes/2)
+		b := netcontext.Baggage{{Key: "a", Value: big}, {Key: "b", Value: big}, {Key: "c", Value: "small"}}
+		s, dropped := b.Encode()
+		if want := []string{"b"}; !slices.Equal(dropped, want) {
+			t.Errorf("expected dropped %v, got %v", want, dropped)
+		}
+		if len(s) > netcontext.MaxBaggageBytes {
+			t.Errorf("expected at most %d bytes, got %d", netcontext.MaxBaggageBytes, len(s))
+		}
+		if !strings.HasSuffix(s, ",c=small") {
+			t.Errorf("expected smaller member to be kept, got ...%s", s[len(s)-20:])
+		}
+	})
+	t.Run("exact byte limit", func(t *testing.T) {
+		b := netcontext.Baggage{{Key: "a", Value: strings.Repeat("x", netcontext.MaxBaggageBytes-2)}}
+		if s, dropped := b.Encode(); len(dropped) > 0 || len(s) != netcontext.MaxBaggageBytes {
+			t.Errorf("expected %d bytes, got %d, dropped %v", netcontext.MaxBaggageBytes, len(s), dropped)
+		}
+	})
+}
+
+func TestPropagator_Encode_baggage(t *testing.T) {
+	var errs []error
+	p := netcontext.NewPropagator(netcontext.Config{
+		Entries: []netcontext.Entry{
+			netcontext.StringEntry(ctxKey("tenant"), "tenant"),
+			netcontext.StringEntry(ctxKey("user"), "user"),
+		},
+		OnError: func(err error) { errs = append(errs, err) },
+	})
+	value := func(k any) any {
+		if k == ctxKey("tenant") {
+			return "acme"
+		}
+		return nil
+	}
+	cfg := netcontext.CarrierConfig{Format: netcontext.BaggageFormat}
+
+	for _, tc := range []struct {
+		name     string
+		existing string
+		want     string
+		wantErrs int
+	}{
+		{name: "no baggage", want: "tenant=acme"},
+		{name: "foreign members", existing: "other=1;p=x,tenant=old;q", want: "other=1;p=x,tenant=acme"},
+		{name: "invalid foreign members", existing: "other=1,bad,x y=2", want: "other=1,tenant=acme", wantErrs: 1},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			errs = nil
+			c := netcontext.MapCarrier{}
+			if tc.existing != "" {
+				c[netcontext.BaggageHeader] = tc.existing
+			}
+			p.Encode(c, cfg, value)
+			if got := c[netcontext.BaggageHeader]; got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+			if len(errs) != tc.wantErrs {
+				t.Errorf("expected %d errors, got %v", tc.wantErrs, errs)
+			}
+		})
+	}
+
+	t.Run("limits", func(t *testing.T) {
+		errs = nil
+		var b netcontext.Baggage
+		for i := range netcontext.MaxBaggageMembers {
+			b.Set(fmt.Sprintf("m%d", i), "v")
+		}
+		c := netcontext.MapCarrier{netcontext.BaggageHeader: b.String()}
+		p.Encode(c, cfg, value)
+		if len(errs) != 1 || !errors.Is(errs[0], netcontext.ErrBaggageTooLarge) {
+			t.Fatalf("expected ErrBaggageTooLarge, got %v", errs)
+		}
+		if !strings.Contains(errs[0].Error(), `"tenant"`) {
+			t.Errorf("expected dropped key in error, got %v", errs[0])
+		}
+	})
+}
+
+func TestPropagator_baggageValues(t *testing.T) {
+	p := netcontext.NewPropagator(netcontext.Config{
+		Entries: []netcontext.Entry{netcontext.StringEntry(ctxKey("city"), "city")},
+	})
+	cfg := netcontext.CarrierConfig{Format: netcontext.BaggageFormat}
+	for _, tc := range []struct {
+		value string
+		wire  string
+	}{
+		{value: "Zürich", wire: "city=Z%C3%BCrich"},
+		{value: " padded ", wire: "city=%20padded%20"},
+		{value: "b64:WsO8cmljaA", wire: "city=b64:WsO8cmljaA"},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			c := netcontext.MapCarrier{}
+			p.Inject(context.WithValue(context.Background(), ctxKey("city"), tc.value), c, cfg)
+			if got := c[netcontext.BaggageHeader]; got != tc.wire {
+				t.Errorf("expected %q, got %q", tc.wire, got)
+			}
+			ctx, _, err := p.Extract(context.Background(), c, cfg, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ctx.Value(ctxKey("city")); got != tc.value {
+				t.Errorf("expected %q, got %v", tc.value, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package netcontext
+
+import (
+	"encoding/base64"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+)
+
+type BytesParseFunc func(b []byte) (any, error)
+
+type BytesFunc func(a any) []byte
+
+// NewBinaryEntry creates an Entry for a context value with a binary
+// representation. Over gRPC, the bytes are sent as a binary ("-bin")
+// metadata value. Where a string is required, as in HTTP headers, they are
+// encoded as unpadded base64url. Both functions are required.
+func NewBinaryEntry(ctxKey any, stringKey string, parse BytesParseFunc, toBytes BytesFunc) Entry {
+	if parse == nil {
+		panic("parser function cannot be nil")
+	}
+	if toBytes == nil {
+		panic("bytes function cannot be nil")
+	}
+	parseValue := func(s string) (any, error) {
+		b, err := DecodeBinary(s)
+		if err != nil {
+			return nil, err
+		}
+		return parse(b)
+	}
+	toString := func(a any) string {
+		return EncodeBinary(toBytes(a))
+	}
+	e := NewEntry(ctxKey, stringKey, parseValue, toString)
+	e.parseBytes = parse
+	e.toBytes = toBytes
+	return e
+}
+
+// Binary reports whether the Entry has a binary representation.
+func (e Entry) Binary() bool {
+	return e.toBytes != nil
+}
+
+// MarshalBytes marshals a value into bytes. For an Entry without a binary
+// representation, these are the bytes of the marshalled string.
+func (e Entry) MarshalBytes(a any) []byte {
+	if e.toBytes == nil {
+		return []byte(e.Marshal(a))
+	}
+	return e.toBytes(a)
+}
+
+// UnmarshalBytes unmarshalls bytes into 'a'. For an Entry without a binary
+// representation, the bytes are parsed as a string.
+func (e Entry) UnmarshalBytes(b []byte, a any) error {
+	if e.parseBytes == nil {
+		return e.Unmarshal(string(b), a)
+	}
+	if err := e.CheckSize(string(b)); err != nil {
+		return err
+	}
+	x, err := e.parseBytes(b)
+	if err != nil {
+		return err
+	}
+	return assign(x, a)
+}
+
+// EncodeBinary encodes bytes as unpadded base64url.
+func EncodeBinary(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// DecodeBinary decodes base64url, with or without padding.
+func DecodeBinary(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// BytesEntry creates an Entry for a []byte context value.
+func BytesEntry(ctxKey any, stringKey string) Entry {
+	parse := func(b []byte) (any, error) {
+		return b, nil
+	}
+	toBytes := func(a any) []byte {
+		b, _ := a.([]byte)
+		return b
+	}
+	return NewBinaryEntry(ctxKey, stringKey, parse, toBytes)
+}
+
+// Bytes adds an Entry for a []byte context value.
+func Bytes(ctxKey any, stringKey string) {
+	Add(BytesEntry(ctxKey, stringKey))
+}
+
+// ProtoEntry creates an Entry for a protocol buffer message context value of
+// type M. Messages are marshalled deterministically; a message that cannot be
+// marshalled is sent as an empty message.
+func ProtoEntry[M proto.Message](ctxKey any, stringKey string) Entry {
+	parse := func(b []byte) (any, error) {
+		var zero M
+		m := zero.ProtoReflect().Type().New().Interface()
+		if err := proto.Unmarshal(b, m); err != nil {
+			return nil, err
+		}
+		return m.(M), nil
+	}
+	toBytes := func(a any) []byte {
+		m, ok := a.(M)
+		if !ok {
+			return nil
+		}
+		b, _ := proto.MarshalOptions{Deterministic: true}.Marshal(m)
+		return b
+	}
+	return NewBinaryEntry(ctxKey, stringKey, parse, toBytes)
+}
+
+// Proto adds an Entry for a protocol buffer message context value of type M.
+func Proto[M proto.Message](ctxKey any, stringKey string) {
+	Add(ProtoEntry[M](ctxKey, stringKey))
+}
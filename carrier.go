@@ -0,0 +1,331 @@
+package netcontext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// A Carrier carries context values over a transport, like HTTP headers, gRPC
+// metadata or message attributes.
+type Carrier interface {
+	// Get returns the values for a key.
+	Get(key string) []string
+	// Set replaces the values for a key. Setting no values removes the key.
+	Set(key string, values ...string)
+	// Keys returns the keys in the carrier.
+	Keys() []string
+}
+
+// A CarrierConfig describes how values are stored in a Carrier.
+type CarrierConfig struct {
+	// Prefix is prepended to the string keys of the entries.
+	Prefix string
+	// Format is the format of the values.
+	Format Format
+	// Binary enables raw bytes for the values of binary entries, stored
+	// under keys with a "-bin" suffix (as in gRPC metadata). Otherwise, they
+	// are base64url-encoded.
+	Binary bool
+}
+
+// Key returns the key for an Entry.
+func (cfg CarrierConfig) Key(e Entry) string {
+	if cfg.Binary && e.Binary() {
+		return cfg.Prefix + e.StringKey() + "-bin"
+	}
+	return cfg.Prefix + e.StringKey()
+}
+
+// raw reports whether the values of the Entry are stored as raw bytes.
+func (cfg CarrierConfig) raw(e Entry) bool {
+	return cfg.Binary && e.Binary() && cfg.Format != BaggageFormat
+}
+
+// Inject stores the context values and deadline in the carrier. Without a
+// deadline, any deadline already in the carrier is removed.
+func (p *Propagator) Inject(ctx context.Context, c Carrier, cfg CarrierConfig) {
+	p.Encode(c, cfg, ctx.Value)
+	t, _ := ctx.Deadline()
+	p.EncodeDeadline(c, cfg, t)
+}
+
+// Extract returns a context with the values found in the carrier. If a
+// deadline is found, it is set on the context and a cancellation function is
+// returned, which will be nil otherwise. Values not accepted from the peer are
+// skipped. Errors are reported and returned.
+func (p *Propagator) Extract(ctx context.Context, c Carrier, cfg CarrierConfig, trusted bool) (context.Context, context.CancelFunc, error) {
+	err := p.Decode(c, cfg, trusted, func(e Entry, a any) {
+		ctx = context.WithValue(ctx, e.CtxKey(), a)
+	})
+	t, ok, err2 := p.DecodeDeadline(c, cfg, trusted)
+	if !ok {
+		return ctx, nil, errors.Join(err, err2)
+	}
+	ctx, cancel := context.WithDeadline(ctx, t)
+	return ctx, cancel, err
+}
+
+// Encode stores the values returned by value in the carrier. In baggage
+// format, the values are merged into the existing baggage; invalid members of
+// the existing baggage and members exceeding the baggage limits are reported
+// and dropped. Entries that are not to be sent are skipped, values that are
+// too large are reported and skipped.
+func (p *Propagator) Encode(c Carrier, cfg CarrierConfig, value func(ctxKey any) any) {
+	if cfg.Format == BaggageFormat {
+		p.encodeBaggage(c, value)
+		return
+	}
+	for _, e := range p.Entries() {
+		if !e.Policy().Sends() {
+			continue
+		}
+		v := value(e.CtxKey())
+		if v == nil {
+			continue
+		}
+		var vs []string
+		if cfg.raw(e) {
+			vs = []string{string(e.MarshalBytes(v))}
+		} else {
+			vs = e.MarshalValues(v)
+		}
+		if err := e.CheckSize(vs...); err != nil {
+			p.Report(err)
+			continue
+		}
+		if len(vs) > 0 {
+			c.Set(cfg.Key(e), vs...)
+		}
+	}
+}
+
+func (p *Propagator) encodeBaggage(c Carrier, value func(ctxKey any) any) {
+	b, err := ParseBaggage(strings.Join(c.Get(BaggageHeader), ","))
+	if err != nil {
+		p.Report(fmt.Errorf("dropping invalid baggage members: %w", err))
+	}
+	for _, e := range p.Entries() {
+		if !e.Policy().Sends() {
+			continue
+		}
+		v := value(e.CtxKey())
+		if v == nil {
+			continue
+		}
+		s := e.marshal(v, false)
+		if err := e.CheckSize(s); err != nil {
+			p.Report(err)
+			continue
+		}
+		b.Set(e.StringKey(), s)
+	}
+	if len(b) == 0 {
+		if err != nil {
+			c.Set(BaggageHeader)
+		}
+		return
+	}
+	v, dropped := b.Encode()
+	if len(dropped) > 0 {
+		p.Report(fmt.Errorf("%w: dropped members %q", ErrBaggageTooLarge, dropped))
+	}
+	c.Set(BaggageHeader, v)
+}
+
+// Decode parses the values found in the carrier and passes them to set.
+// Values not accepted from the peer are skipped. Errors are reported and
+// returned.
+func (p *Propagator) Decode(c Carrier, cfg CarrierConfig, trusted bool, set func(e Entry, a any)) error {
+	get, err := p.lookup(c, cfg)
+	errs := []error{err}
+	for _, e := range p.Entries() {
+		if !e.Policy().Accepts(trusted) {
+			continue
+		}
+		vs := get(e)
+		if len(vs) == 0 || !e.Multi() && vs[0] == "" {
+			continue
+		}
+		var a any
+		var err error
+		switch {
+		case cfg.raw(e):
+			err = e.UnmarshalBytes([]byte(vs[0]), &a)
+		case cfg.Format == BaggageFormat:
+			err = e.unmarshal(vs[0], &a, false)
+		default:
+			err = e.UnmarshalValues(vs, &a)
+		}
+		if err != nil {
+			err = &ParseError{Entry: e, Raw: strings.Join(vs, ","), Err: err}
+			p.Report(err)
+			errs = append(errs, err)
+			continue
+		}
+		set(e, a)
+	}
+	return errors.Join(errs...)
+}
+
+// lookup returns a function that looks up the raw values for an Entry. Invalid
+// baggage members are reported and returned as an error; the valid members are
+// still looked up.
+func (p *Propagator) lookup(c Carrier, cfg CarrierConfig) (func(e Entry) []string, error) {
+	if cfg.Format == BaggageFormat {
+		b, err := ParseBaggage(strings.Join(c.Get(BaggageHeader), ","))
+		if err != nil {
+			err = fmt.Errorf("error parsing baggage: %w", err)
+			p.Report(err)
+		}
+		return func(e Entry) []string {
+			if v, ok := b.Get(e.StringKey()); ok {
+				return []string{v}
+			}
+			return nil
+		}, err
+	}
+	return func(e Entry) []string {
+		return c.Get(cfg.Key(e))
+	}, nil
+}
+
+// EncodeDeadline stores the deadline in the carrier. A zero deadline removes
+// any deadline already in the carrier, so that it is not propagated by
+// mistake. It does nothing if the deadline is not propagated.
+func (p *Propagator) EncodeDeadline(c Carrier, cfg CarrierConfig, t time.Time) {
+	e, ok := p.Deadline()
+	if !ok {
+		return
+	}
+	if t.IsZero() {
+		c.Set(cfg.Key(e))
+		return
+	}
+	c.Set(cfg.Key(e), e.Marshal(t))
+}
+
+// DecodeDeadline returns the deadline found in the carrier. It returns false
+// if there is none, or if it is not accepted from the peer. Errors are
+// reported and returned.
+func (p *Propagator) DecodeDeadline(c Carrier, cfg CarrierConfig, trusted bool) (time.Time, bool, error) {
+	e, ok := p.Deadline()
+	if !ok || !p.AcceptsDeadline(trusted) {
+		return time.Time{}, false, nil
+	}
+	vs := c.Get(cfg.Key(e))
+	if len(vs) == 0 || vs[0] == "" {
+		return time.Time{}, false, nil
+	}
+	t, err := p.ParseDeadline(vs[0])
+	if err != nil {
+		p.Report(err)
+		return time.Time{}, false, err
+	}
+	return t, true, nil
+}
+
+// Inject stores the context values and deadline in the carrier, using the
+// HTTP header prefix.
+func Inject(ctx context.Context, c Carrier) {
+	std.Inject(ctx, c, CarrierConfig{Prefix: std.HTTPHeaderPrefix()})
+}
+
+// Extract returns a context with the values found in the carrier, which is
+// considered to come from a trusted peer. If a deadline is found, it is set on
+// the context and a cancellation function is returned, which will be nil
+// otherwise.
+func Extract(ctx context.Context, c Carrier) (context.Context, context.CancelFunc) {
+	ctx, cancel, _ := ExtractE(ctx, c)
+	return ctx, cancel
+}
+
+// ExtractE works as Extract, but also returns the errors encountered.
+func ExtractE(ctx context.Context, c Carrier) (context.Context, context.CancelFunc, error) {
+	return std.Extract(ctx, c, CarrierConfig{Prefix: std.HTTPHeaderPrefix()}, true)
+}
+
+// A MapCarrier is a Carrier for a map with a single value per key, like the
+// attributes of a message. Multiple values are joined with commas.
+type MapCarrier map[string]string
+
+func (c MapCarrier) Get(key string) []string {
+	if v, ok := c[key]; ok {
+		return []string{v}
+	}
+	return nil
+}
+
+func (c MapCarrier) Set(key string, values ...string) {
+	if len(values) == 0 {
+		delete(c, key)
+		return
+	}
+	c[key] = strings.Join(values, ",")
+}
+
+func (c MapCarrier) Keys() []string {
+	return slices.Collect(maps.Keys(c))
+}
+
+// A MultiMapCarrier is a Carrier for a map with multiple values per key.
+type MultiMapCarrier map[string][]string
+
+func (c MultiMapCarrier) Get(key string) []string {
+	return c[key]
+}
+
+func (c MultiMapCarrier) Set(key string, values ...string) {
+	if len(values) == 0 {
+		delete(c, key)
+		return
+	}
+	c[key] = slices.Clone(values)
+}
+
+func (c MultiMapCarrier) Keys() []string {
+	return slices.Collect(maps.Keys(c))
+}
+
+// A Header is a message header with a binary value, as used by Kafka.
+type Header struct {
+	Key   string
+	Value []byte
+}
+
+// A HeaderList is a Carrier for a list of message headers. Keys may appear
+// multiple times.
+type HeaderList []Header
+
+func (c *HeaderList) Get(key string) []string {
+	var vs []string
+	for _, h := range *c {
+		if h.Key == key {
+			vs = append(vs, string(h.Value))
+		}
+	}
+	return vs
+}
+
+func (c *HeaderList) Set(key string, values ...string) {
+	*c = slices.DeleteFunc(*c, func(h Header) bool {
+		return h.Key == key
+	})
+	for _, v := range values {
+		*c = append(*c, Header{Key: key, Value: []byte(v)})
+	}
+}
+
+func (c *HeaderList) Keys() []string {
+	var ks []string
+	for _, h := range *c {
+		if !slices.Contains(ks, h.Key) {
+			ks = append(ks, h.Key)
+		}
+	}
+	return ks
+}
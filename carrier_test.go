@@ -0,0 +1,54 @@
+package netcontext_test
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/HayoVanLoon/go-netcontext"
+	nchttp "github.com/HayoVanLoon/go-netcontext/http"
+)
+
+func TestPropagator_Decode_multi(t *testing.T) {
+	p := netcontext.NewPropagator(netcontext.Config{
+		Entries: []netcontext.Entry{
+			netcontext.StringSliceEntry(ctxKey("ss"), "ss"),
+			netcontext.StringEntry(ctxKey("s"), "s"),
+		},
+	})
+	for _, tc := range []struct {
+		name   string
+		format netcontext.Format
+		value  []string
+	}{
+		{name: "header", value: []string{"a", "b"}},
+		{name: "header, empty first", value: []string{"", "a"}},
+		{name: "header, empty last", value: []string{"a", ""}},
+		{name: "baggage, empty first", format: netcontext.BaggageFormat, value: []string{"", "a"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := netcontext.CarrierConfig{Prefix: "x-", Format: tc.format}
+			ctx := context.WithValue(context.Background(), ctxKey("ss"), tc.value)
+			h := http.Header{}
+			p.Inject(ctx, nchttp.HeaderCarrier(h), cfg)
+			ctx, _, err := p.Extract(context.Background(), nchttp.HeaderCarrier(h), cfg, true)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := ctx.Value(ctxKey("ss")); !reflect.DeepEqual(got, tc.value) {
+				t.Errorf("expected %q, got %#v (header %v)", tc.value, got, h)
+			}
+		})
+	}
+	t.Run("empty single value", func(t *testing.T) {
+		cfg := netcontext.CarrierConfig{Prefix: "x-"}
+		ctx, _, err := p.Extract(context.Background(), netcontext.MapCarrier{"x-s": ""}, cfg, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ctx.Value(ctxKey("s")); got != nil {
+			t.Errorf("expected no value, got %#v", got)
+		}
+	})
+}
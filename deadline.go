@@ -0,0 +1,272 @@
+package netcontext
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// A DeadlineMode determines how the standard Go context deadline is
+// propagated.
+type DeadlineMode int
+
+const (
+	// AbsoluteDeadline propagates the deadline as a timestamp (using
+	// TimeFormat). This is the default. It requires the clocks of the hosts
+	// involved to be in sync.
+	AbsoluteDeadline DeadlineMode = iota
+	// RelativeDeadline propagates the remaining time, using the format of the
+	// gRPC 'grpc-timeout' header (i.e. "1500m"). The receiver computes the
+	// deadline from the time of receipt, making it insensitive to clock skew.
+	RelativeDeadline
+)
+
+var deadline = TimeEntry(nil, "Deadline")
+
+var timeout = timeoutEntry(nil, "Timeout")
+
+func timeoutEntry(ctxKey any, stringKey string) Entry {
+	parse := func(s string) (any, error) {
+		d, err := parseTimeout(s)
+		if err != nil {
+			return nil, err
+		}
+		return time.Now().Add(d), nil
+	}
+	toString := func(a any) string {
+		t, ok := a.(time.Time)
+		if !ok {
+			return ""
+		}
+		return formatTimeout(time.Until(t))
+	}
+	return NewEntry(ctxKey, stringKey, parse, toString)
+}
+
+// maxTimeoutValue is the largest value allowed by the grpc-timeout format
+// (eight digits).
+const maxTimeoutValue = 99_999_999
+
+var timeoutUnits = []struct {
+	unit   time.Duration
+	suffix byte
+}{
+	{time.Nanosecond, 'n'},
+	{time.Microsecond, 'u'},
+	{time.Millisecond, 'm'},
+	{time.Second, 'S'},
+	{time.Minute, 'M'},
+	{time.Hour, 'H'},
+}
+
+// formatTimeout formats a duration in milliseconds or, if that does not fit,
+// the smallest coarser unit that does. Durations under a millisecond are
+// formatted in micro- or nanoseconds, so that they do not expire on receipt.
+// Values are truncated.
+func formatTimeout(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return "0m"
+	case d < time.Microsecond:
+		return strconv.FormatInt(int64(d), 10) + "n"
+	case d < time.Millisecond:
+		return strconv.FormatInt(int64(d/time.Microsecond), 10) + "u"
+	}
+	for _, u := range timeoutUnits[2:] {
+		if v := d / u.unit; v <= maxTimeoutValue {
+			return strconv.FormatInt(int64(v), 10) + string(u.suffix)
+		}
+	}
+	return strconv.FormatInt(maxTimeoutValue, 10) + "H"
+}
+
+// parseTimeout parses a duration in grpc-timeout format. Durations that do not
+// fit in a time.Duration are clamped to its maximum, as gRPC does.
+func parseTimeout(s string) (time.Duration, error) {
+	if len(s) < 2 || len(s) > 9 {
+		return 0, fmt.Errorf("invalid timeout %q", s)
+	}
+	v, err := strconv.ParseUint(s[:len(s)-1], 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timeout %q", s)
+	}
+	for _, u := range timeoutUnits {
+		if u.suffix == s[len(s)-1] {
+			if v > uint64(math.MaxInt64/u.unit) {
+				return math.MaxInt64, nil
+			}
+			return time.Duration(v) * u.unit, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid timeout unit in %q", s)
+}
+
+// Deadline returns the Entry to be used for propagating the standard Go
+// context deadline. Its value type is time.Time, regardless of the mode.
+func (p *Propagator) Deadline() (Entry, bool) {
+	if p.config.NoDeadline {
+		return Entry{}, false
+	}
+	if p.config.DeadlineMode == RelativeDeadline {
+		return timeout, true
+	}
+	return deadline, true
+}
+
+// ParseDeadline parses a propagated deadline and subtracts the configured
+// safety margin. It should be called upon receipt. Parsing errors are returned
+// as a *ParseError.
+func (p *Propagator) ParseDeadline(s string) (time.Time, error) {
+	e, ok := p.Deadline()
+	if !ok {
+		return time.Time{}, fmt.Errorf("deadline propagation is disabled")
+	}
+	var t time.Time
+	if err := e.Unmarshal(s, &t); err != nil {
+		return time.Time{}, &ParseError{Entry: e, Raw: s, Err: err}
+	}
+	return t.Add(-p.config.DeadlineMargin), nil
+}
+
+// Deadline returns the Entry to be used for propagating the standard Go
+// context.
+func Deadline() (Entry, bool) {
+	return std.Deadline()
+}
+
+// NoStandardDeadLine will disable propagation of the standard Go context
+// deadline. By default, it is enabled.
+func NoStandardDeadLine() {
+	std.config.NoDeadline = true
+}
+
+// SetDeadlineMode sets how the standard Go context deadline is propagated.
+func SetDeadlineMode(mode DeadlineMode) {
+	std.config.DeadlineMode = mode
+}
+
+// SetDeadlineMargin sets the safety margin that is subtracted from incoming
+// deadlines, leaving time to respond before the caller gives up.
+func SetDeadlineMargin(margin time.Duration) {
+	std.config.DeadlineMargin = margin
+}
+
+// ErrDeadlineTooShort is returned when the remaining time of a deadline is
+// below the minimum of the DeadlineBounds.
+var ErrDeadlineTooShort = errors.New("remaining time before deadline is too short")
+
+// DeadlineBounds limit the remaining time of incoming deadlines. Zero values
+// disable a bound.
+type DeadlineBounds struct {
+	// Max is the maximum remaining time. Later deadlines are clamped.
+	Max time.Duration
+	// Min is the minimum remaining time. Requests with less time left are
+	// rejected.
+	Min time.Duration
+}
+
+// Apply applies the bounds to the deadline of the context. If the context has
+// no deadline or needs no clamping, it is returned unchanged with a nil
+// cancellation function. If the remaining time is too short,
+// ErrDeadlineTooShort is returned.
+func (b DeadlineBounds) Apply(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	t, ok := ctx.Deadline()
+	if !ok {
+		return ctx, nil, nil
+	}
+	left := time.Until(t)
+	if b.Min > 0 && left < b.Min {
+		return ctx, nil, ErrDeadlineTooShort
+	}
+	if b.Max > 0 && left > b.Max {
+		ctx, cancel := context.WithTimeout(ctx, b.Max)
+		return ctx, cancel, nil
+	}
+	return ctx, nil, nil
+}
+
+// DeadlineBounds returns the bounds for incoming deadlines.
+func (p *Propagator) DeadlineBounds() DeadlineBounds {
+	return p.config.DeadlineBounds
+}
+
+// SetDeadlineBounds sets the bounds for incoming deadlines.
+func SetDeadlineBounds(b DeadlineBounds) {
+	std.config.DeadlineBounds = b
+}
+
+// ErrBudgetExhausted is returned in fail-fast mode when the deadline has (all
+// but) passed. It wraps context.DeadlineExceeded.
+var ErrBudgetExhausted = fmt.Errorf("time budget exhausted: %w", context.DeadlineExceeded)
+
+// CheckIncoming returns ErrBudgetExhausted if fail-fast mode is enabled and
+// the deadline of the context has passed.
+func (p *Propagator) CheckIncoming(ctx context.Context) error {
+	if !p.config.FailFast {
+		return nil
+	}
+	if t, ok := ctx.Deadline(); ok && !time.Now().Before(t) {
+		return ErrBudgetExhausted
+	}
+	return nil
+}
+
+// CheckOutgoing returns ErrBudgetExhausted if fail-fast mode is enabled and
+// the time left before the deadline of the context is below the threshold.
+func (p *Propagator) CheckOutgoing(ctx context.Context) error {
+	if !p.config.FailFast {
+		return nil
+	}
+	if t, ok := ctx.Deadline(); ok && time.Until(t) <= p.config.FailFastThreshold {
+		return ErrBudgetExhausted
+	}
+	return nil
+}
+
+// SetFailFast enables fail-fast mode. Server wrappers and interceptors then
+// reject requests whose deadline has passed, without invoking the handler.
+// Clients refuse to send requests with less time left than the threshold.
+func SetFailFast(threshold time.Duration) {
+	std.config.FailFast = true
+	std.config.FailFastThreshold = threshold
+}
+
+// A Reservation is the part of the remaining time a service keeps for itself
+// when propagating its deadline, leaving it time to handle a downstream
+// failure. The fixed duration and percentage are added up.
+type Reservation struct {
+	// Fixed is a fixed duration to reserve.
+	Fixed time.Duration
+	// Percent is the percentage (0-100) of the remaining time to reserve.
+	Percent float64
+}
+
+// Apply returns the deadline to propagate for a deadline. Deadlines that have
+// already passed are returned unchanged. A negative Fixed duration counts as
+// zero, Percent is clamped to 0-100.
+func (r Reservation) Apply(t time.Time) time.Time {
+	left := time.Until(t)
+	if left <= 0 {
+		return t
+	}
+	fixed := max(r.Fixed, 0)
+	pct := r.Percent
+	if !(pct > 0) {
+		pct = 0
+	}
+	pct = min(pct, 100)
+	return t.Add(-fixed - time.Duration(float64(left)*pct/100))
+}
+
+// Reservation returns the Reservation for outgoing deadlines.
+func (p *Propagator) Reservation() Reservation {
+	return p.config.Reservation
+}
+
+// SetReservation sets the Reservation for outgoing deadlines.
+func SetReservation(r Reservation) {
+	std.config.Reservation = r
+}
@@ -0,0 +1,86 @@
+package netcontext
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestParseTimeout(t *testing.T) {
+	const maxDuration = time.Duration(math.MaxInt64)
+	for _, tc := range []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{in: "1500m", want: 1500 * time.Millisecond},
+		{in: "0m", want: 0},
+		{in: "7n", want: 7},
+		{in: "250u", want: 250 * time.Microsecond},
+		{in: "30S", want: 30 * time.Second},
+		{in: "5M", want: 5 * time.Minute},
+		{in: "2H", want: 2 * time.Hour},
+		{in: "99999999n", want: 99_999_999},
+		{in: "99999999S", want: 99_999_999 * time.Second},
+		{in: "2562047H", want: 2_562_047 * time.Hour},
+		{in: "2562048H", want: maxDuration},
+		{in: "9999999H", want: maxDuration},
+		{in: "99999999H", want: maxDuration},
+		{in: "99999999M", want: 99_999_999 * time.Minute},
+		{in: "m", wantErr: true},
+		{in: "5", wantErr: true},
+		{in: "5x", wantErr: true},
+		{in: "-5m", wantErr: true},
+		{in: "+5m", wantErr: true},
+		{in: "999999999m", wantErr: true},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := parseTimeout(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if got != tc.want {
+				t.Errorf("expected %v, got %v", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestFormatTimeout(t *testing.T) {
+	for _, tc := range []struct {
+		in   time.Duration
+		want string
+	}{
+		{in: -time.Second, want: "0m"},
+		{in: 0, want: "0m"},
+		{in: 1, want: "1n"},
+		{in: 999, want: "999n"},
+		{in: time.Microsecond, want: "1u"},
+		{in: 999 * time.Microsecond, want: "999u"},
+		{in: time.Millisecond, want: "1m"},
+		{in: 1500*time.Millisecond + 700*time.Microsecond, want: "1500m"},
+		{in: 99_999_999 * time.Millisecond, want: "99999999m"},
+		{in: 100_000_000 * time.Millisecond, want: "100000S"},
+		{in: 100_000_000 * time.Second, want: "1666666M"},
+		{in: math.MaxInt64, want: "2562047H"},
+	} {
+		t.Run(tc.in.String(), func(t *testing.T) {
+			if got := formatTimeout(tc.in); got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestParseDeadline_overflow(t *testing.T) {
+	p := NewPropagator(Config{DeadlineMode: RelativeDeadline})
+	for _, s := range []string{"2562048H", "9999999H", "99999999H"} {
+		got, err := p.ParseDeadline(s)
+		if err != nil {
+			t.Fatalf("unexpected error for %q: %v", s, err)
+		}
+		if time.Until(got) < 100_000*time.Hour {
+			t.Errorf("expected a far-away deadline for %q, got %v", s, got)
+		}
+	}
+}
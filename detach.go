@@ -0,0 +1,39 @@
+package netcontext
+
+import (
+	"context"
+	"time"
+)
+
+// A DetachOption configures Detach.
+type DetachOption func(*detachOptions)
+
+type detachOptions struct {
+	budget time.Duration
+}
+
+// WithBudget gives a detached context a fresh deadline, the given duration
+// from now.
+func WithBudget(d time.Duration) DetachOption {
+	return func(o *detachOptions) {
+		o.budget = d
+	}
+}
+
+// Detach returns a context for work that outlives the request it was started
+// from. The values of the context are kept, but its deadline and cancellation
+// are dropped. With WithBudget, a new deadline is set and a cancellation
+// function is returned, which will be nil otherwise. Client wrappers and
+// interceptors propagate the new deadline, or none at all. Since the response
+// has likely been sent by then, values can no longer be marked with Respond.
+func Detach(ctx context.Context, opts ...DetachOption) (context.Context, context.CancelFunc) {
+	o := &detachOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	ctx = context.WithValue(context.WithoutCancel(ctx), outgoingResponseKey{}, (*Response)(nil))
+	if o.budget <= 0 {
+		return ctx, nil
+	}
+	return context.WithTimeout(ctx, o.budget)
+}
@@ -0,0 +1,118 @@
+package netcontext
+
+import (
+	"context"
+	"os"
+	"slices"
+	"strings"
+)
+
+// DefaultEnvPrefix is the default prefix for environment variables.
+const DefaultEnvPrefix = "GO_CONTEXT_"
+
+// EnvPrefix returns the prefix for environment variables.
+func (p *Propagator) EnvPrefix() string {
+	return p.config.EnvPrefix
+}
+
+// EnvPrefix returns the prefix for environment variables.
+func EnvPrefix() string {
+	return std.EnvPrefix()
+}
+
+// SetEnvPrefix sets the prefix for environment variables.
+func SetEnvPrefix(prefix string) {
+	std.config.EnvPrefix = prefix
+}
+
+// An EnvCarrier is a Carrier for environment variables in "KEY=value" form,
+// as in os.Environ and exec.Cmd.Env. Keys are converted to variable names by
+// upper-casing them and replacing '-' and '.' by '_'. Multiple values are
+// joined with commas.
+type EnvCarrier []string
+
+// EnvName returns the name of the environment variable for a key.
+func EnvName(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '.' {
+			return '_'
+		}
+		return r
+	}, strings.ToUpper(key))
+}
+
+// Get returns the value of the variable. If it is set multiple times, the
+// last value is used.
+func (c *EnvCarrier) Get(key string) []string {
+	name := EnvName(key) + "="
+	for i := len(*c) - 1; i >= 0; i-- {
+		if v, ok := strings.CutPrefix((*c)[i], name); ok {
+			return []string{v}
+		}
+	}
+	return nil
+}
+
+func (c *EnvCarrier) Set(key string, values ...string) {
+	name := EnvName(key)
+	*c = slices.DeleteFunc(*c, func(kv string) bool {
+		return strings.HasPrefix(kv, name+"=")
+	})
+	if len(values) > 0 {
+		*c = append(*c, name+"="+strings.Join(values, ","))
+	}
+}
+
+func (c *EnvCarrier) Keys() []string {
+	ks := make([]string, 0, len(*c))
+	for _, kv := range *c {
+		k, _, _ := strings.Cut(kv, "=")
+		if !slices.Contains(ks, k) {
+			ks = append(ks, k)
+		}
+	}
+	return ks
+}
+
+// Environment returns the environment variables for the context values and
+// deadline, using the prefix for environment variables. As with outgoing
+// requests, entries that are not sent to peers are skipped. Sensitive values
+// are included; since the environment of a process can often be read by
+// others, consider skipping them with WithoutSensitive.
+func (p *Propagator) Environment(ctx context.Context, opts ...CaptureOption) []string {
+	o := newCaptureOptions(opts)
+	value := ctx.Value
+	if o.noSensitive {
+		value = func(ctxKey any) any {
+			for _, e := range p.Entries() {
+				if e.CtxKey() == ctxKey && o.skip(e) {
+					return nil
+				}
+			}
+			return ctx.Value(ctxKey)
+		}
+	}
+	var env EnvCarrier
+	cfg := CarrierConfig{Prefix: p.EnvPrefix()}
+	p.Encode(&env, cfg, value)
+	t, _ := ctx.Deadline()
+	p.EncodeDeadline(&env, cfg, t)
+	return env
+}
+
+// FromEnvironment returns a context with the values and deadline found in the
+// environment variables. If a deadline is found, a cancellation function is
+// returned, which will be nil otherwise. Errors are reported and returned.
+func (p *Propagator) FromEnvironment(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	env := EnvCarrier(os.Environ())
+	return p.Extract(ctx, &env, CarrierConfig{Prefix: p.EnvPrefix()}, true)
+}
+
+// FromEnvironment returns a context with the values and deadline found in the
+// environment variables of the process, as set by a parent process. It is
+// meant to be called at the start of main. If a deadline is found, a
+// cancellation function is returned, which will be nil otherwise.
+func FromEnvironment(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel, _ := std.FromEnvironment(ctx)
+	return ctx, cancel
+}
@@ -0,0 +1,41 @@
+package netcontext_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/HayoVanLoon/go-netcontext"
+)
+
+func TestPropagator_Environment(t *testing.T) {
+	p := netcontext.NewPropagator(netcontext.Config{
+		EnvPrefix:  "T_",
+		NoDeadline: true,
+		Entries: []netcontext.Entry{
+			netcontext.StringEntry(ctxKey("tenant"), "tenant"),
+			netcontext.StringEntry(ctxKey("tok"), "tok").WithSensitive(true),
+			netcontext.StringEntry(ctxKey("local"), "local").WithPolicy(netcontext.Policy{Outbound: netcontext.SendNone}),
+		},
+	})
+	ctx := context.WithValue(context.Background(), ctxKey("tenant"), "acme")
+	ctx = context.WithValue(ctx, ctxKey("tok"), "secret")
+	ctx = context.WithValue(ctx, ctxKey("local"), "x")
+
+	for _, tc := range []struct {
+		name string
+		opts []netcontext.CaptureOption
+		want []string
+	}{
+		{name: "default", want: []string{"T_TENANT=acme", "T_TOK=secret"}},
+		{name: "without sensitive", opts: []netcontext.CaptureOption{netcontext.WithoutSensitive()}, want: []string{"T_TENANT=acme"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := p.Environment(ctx, tc.opts...)
+			slices.Sort(got)
+			if !slices.Equal(got, tc.want) {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
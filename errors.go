@@ -0,0 +1,70 @@
+package netcontext
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// A ParseError is returned when an incoming value cannot be parsed.
+type ParseError struct {
+	Entry Entry
+	Raw   string
+	Err   error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("could not parse value %q for key %q: %v", e.Raw, e.Entry.StringKey(), e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ErrValueTooLarge is returned for values exceeding the maximum size of their
+// Entry.
+var ErrValueTooLarge = errors.New("value too large")
+
+// ErrBaggageTooLarge is returned when baggage members are dropped to stay
+// within the limits of the W3C baggage specification.
+var ErrBaggageTooLarge = errors.New("baggage too large")
+
+// An ErrorFunc handles errors encountered while propagating values.
+type ErrorFunc func(err error)
+
+// Report reports an error to the configured error function. Without one, the
+// error is logged, at warning level if a structured logger is configured.
+func (p *Propagator) Report(err error) {
+	if p.config.OnError != nil {
+		p.config.OnError(err)
+		return
+	}
+	if p.config.Logger == nil {
+		p.Log("%s", err.Error())
+		return
+	}
+	var pe *ParseError
+	if errors.As(err, &pe) {
+		p.config.Logger.Warn(err.Error(), slog.String("key", pe.Entry.StringKey()))
+		return
+	}
+	p.config.Logger.Warn(err.Error())
+}
+
+// Strict reports whether strict mode is enabled. In strict mode, server
+// wrappers and interceptors reject requests with values that cannot be
+// parsed.
+func (p *Propagator) Strict() bool {
+	return p.config.Strict
+}
+
+// SetErrorHandler sets the function errors are reported to. Setting it to nil
+// will have errors logged instead.
+func SetErrorHandler(f ErrorFunc) {
+	std.config.OnError = f
+}
+
+// SetStrict enables or disables strict mode.
+func SetStrict(strict bool) {
+	std.config.Strict = strict
+}
@@ -0,0 +1,72 @@
+package netcontext
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// encodedPrefix marks a value that has been base64url-encoded to make it safe
+// to send.
+const encodedPrefix = "b64:"
+
+// escape returns a string that is safe to use as an HTTP header or gRPC
+// metadata value. Values that are not, or that start with encodedPrefix, are
+// base64url-encoded and prefixed with encodedPrefix. Others are returned
+// as-is.
+func escape(s string) string {
+	if isSafe(s) && !strings.HasPrefix(s, encodedPrefix) {
+		return s
+	}
+	return encodedPrefix + base64.RawURLEncoding.EncodeToString([]byte(s))
+}
+
+// unescape reverses escape.
+func unescape(s string) (string, error) {
+	if !strings.HasPrefix(s, encodedPrefix) {
+		return s, nil
+	}
+	b, err := DecodeBinary(s[len(encodedPrefix):])
+	if err != nil {
+		return "", fmt.Errorf("invalid encoded value: %w", err)
+	}
+	return string(b), nil
+}
+
+// isSafe reports whether s only consists of printable ASCII characters,
+// without leading or trailing spaces.
+func isSafe(s string) bool {
+	if strings.HasPrefix(s, " ") || strings.HasSuffix(s, " ") {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if !isPrintable(s[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func isPrintable(c byte) bool {
+	return c >= 0x20 && c < 0x7f
+}
+
+// ValidateKey returns an error if the string key of an Entry cannot be used
+// in HTTP header names and gRPC metadata keys. Valid keys are non-empty and
+// consist of ASCII letters, digits, '-', '_' and '.'. Since it is reserved for
+// binary values, the "-bin" suffix is not allowed.
+func ValidateKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("key cannot be empty")
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.') {
+			return fmt.Errorf("invalid character %q in key %q", c, key)
+		}
+	}
+	if strings.HasSuffix(strings.ToLower(key), "-bin") {
+		return fmt.Errorf("key %q has reserved suffix \"-bin\"", key)
+	}
+	return nil
+}
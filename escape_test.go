@@ -0,0 +1,89 @@
+package netcontext
+
+import (
+	"testing"
+)
+
+func TestEscape(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want string
+	}{
+		{in: "", want: ""},
+		{in: "plain", want: "plain"},
+		{in: "with inner space", want: "with inner space"},
+		{in: `a,b;c="d"`, want: `a,b;c="d"`},
+		{in: " leading", want: "b64:IGxlYWRpbmc"},
+		{in: "trailing ", want: "b64:dHJhaWxpbmcg"},
+		{in: "Zürich", want: "b64:WsO8cmljaA"},
+		{in: "a\nb", want: "b64:YQpi"},
+		{in: "\x7f", want: "b64:fw"},
+		{in: "b64:YQ", want: "b64:YjY0OllR"},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			got := escape(tc.in)
+			if got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+			back, err := unescape(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if back != tc.in {
+				t.Errorf("expected %q after unescaping, got %q", tc.in, back)
+			}
+		})
+	}
+}
+
+func TestUnescape(t *testing.T) {
+	for _, tc := range []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{in: "plain", want: "plain"},
+		{in: "b64:", want: ""},
+		{in: "b64:YQpi", want: "a\nb"},
+		{in: "b64:YQpi==", want: "a\nb"},
+		{in: "B64:YQpi", want: "B64:YQpi"},
+		{in: "b64:!!", wantErr: true},
+	} {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := unescape(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("expected error %v, got %v", tc.wantErr, err)
+			}
+			if got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateKey(t *testing.T) {
+	for _, tc := range []struct {
+		key     string
+		wantErr bool
+	}{
+		{key: "Tenant"},
+		{key: "tenant-id"},
+		{key: "tenant_id.v2"},
+		{key: "X1"},
+		{key: "binary"},
+		{key: "bin"},
+		{key: "", wantErr: true},
+		{key: "tenant id", wantErr: true},
+		{key: "tenant:id", wantErr: true},
+		{key: "ténant", wantErr: true},
+		{key: "tenant\n", wantErr: true},
+		{key: "data-bin", wantErr: true},
+		{key: "data-BIN", wantErr: true},
+	} {
+		t.Run(tc.key, func(t *testing.T) {
+			if err := ValidateKey(tc.key); (err != nil) != tc.wantErr {
+				t.Errorf("expected error %v, got %v", tc.wantErr, err)
+			}
+		})
+	}
+}
@@ -73,8 +73,11 @@ func (ex *ExampleService) Deadline(ctx context.Context, req *pb.DeadlineRequest)
 }
 
 func main() {
-	// Use the interceptor for incoming requests.
-	srv := grpc.NewServer(grpc.UnaryInterceptor(ncgrpc.UnaryServerInterceptor))
+	// Use the interceptors for incoming requests.
+	srv := grpc.NewServer(
+		grpc.UnaryInterceptor(ncgrpc.UnaryServerInterceptor),
+		grpc.StreamInterceptor(ncgrpc.StreamServerInterceptor),
+	)
 	// Wrap the default http.Client for outgoing requests.
 	httpClient := nchttp.WrapClient(http.DefaultClient)
 	client := shared.NewExampleHTTPClient(httpClient)
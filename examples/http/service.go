@@ -101,8 +101,9 @@ func main() {
 
 	opts := []grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
-		// Use the client interceptor for outgoing requests.
+		// Use the client interceptors for outgoing requests.
 		grpc.WithUnaryInterceptor(ncgrpc.UnaryClientIntercept),
+		grpc.WithStreamInterceptor(ncgrpc.StreamClientInterceptor),
 	}
 	conn, err := grpc.NewClient(shared.Target, opts...)
 	if err != nil {
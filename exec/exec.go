@@ -0,0 +1,42 @@
+package exec
+
+import (
+	"context"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/HayoVanLoon/go-netcontext"
+)
+
+// Command works as exec.CommandContext, but also passes the configured
+// context values and deadline to the command in environment variables. The
+// command can restore them using netcontext.FromEnvironment. Sensitive values
+// are included; to skip them, set the environment using
+// Propagator.Environment with netcontext.WithoutSensitive instead.
+func Command(ctx context.Context, name string, arg ...string) *exec.Cmd {
+	return NewCommand(netcontext.Default(), ctx, name, arg...)
+}
+
+// NewCommand works as Command, but uses the given Propagator.
+func NewCommand(p *netcontext.Propagator, ctx context.Context, name string, arg ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, arg...)
+	inject(p, ctx, cmd)
+	return cmd
+}
+
+// Inject adds environment variables for the configured context values and
+// deadline to the command. If its environment is not set, it starts from that
+// of the current process. Variables with the prefix that are already present,
+// like those inherited from a parent process, are removed first.
+func Inject(ctx context.Context, cmd *exec.Cmd) {
+	inject(netcontext.Default(), ctx, cmd)
+}
+
+func inject(p *netcontext.Propagator, ctx context.Context, cmd *exec.Cmd) {
+	prefix := netcontext.EnvName(p.EnvPrefix())
+	cmd.Env = slices.DeleteFunc(cmd.Environ(), func(kv string) bool {
+		return strings.HasPrefix(kv, prefix)
+	})
+	cmd.Env = append(cmd.Env, p.Environment(ctx)...)
+}
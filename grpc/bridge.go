@@ -0,0 +1,49 @@
+package grpc
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/HayoVanLoon/go-netcontext"
+)
+
+// MetadataFromHeader translates the configured values and deadline in the
+// HTTP headers to gRPC metadata, replacing the HTTP header prefix with the
+// gRPC metadata prefix. Values are copied as-is, without being parsed, but
+// binary values are base64url-decoded. Other headers are ignored. As when
+// extracting, values not accepted from the sender (according to trusted) are
+// skipped; entries that are not sent to peers are skipped as well.
+func MetadataFromHeader(h http.Header, trusted bool) metadata.MD {
+	return metadataFromHeader(netcontext.Default(), h, trusted)
+}
+
+func metadataFromHeader(p *netcontext.Propagator, h http.Header, trusted bool) metadata.MD {
+	md := metadata.MD{}
+	from := netcontext.CarrierConfig{Prefix: p.HTTPHeaderPrefix()}
+	to := newOptions(nil).carrierConfig(p)
+	cp := func(e netcontext.Entry) {
+		vs := h.Values(from.Key(e))
+		if len(vs) == 0 {
+			return
+		}
+		if e.Binary() {
+			b, err := netcontext.DecodeBinary(vs[0])
+			if err != nil {
+				p.Report(&netcontext.ParseError{Entry: e, Raw: vs[0], Err: err})
+				return
+			}
+			vs = []string{string(b)}
+		}
+		md.Append(to.Key(e), vs...)
+	}
+	for _, e := range p.Entries() {
+		if e.Policy().Accepts(trusted) && e.Policy().Sends() {
+			cp(e)
+		}
+	}
+	if e, ok := p.Deadline(); ok && p.AcceptsDeadline(trusted) {
+		cp(e)
+	}
+	return md
+}
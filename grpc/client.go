@@ -2,34 +2,164 @@ package grpc
 
 import (
 	"context"
+	"sync"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/HayoVanLoon/go-netcontext"
 )
 
 // UnaryClientIntercept intercepts an outgoing request, adding metadata keys
-// for the configured context values and deadline.
+// for the configured context values and deadline. If the context holds an
+// incoming Response (see netcontext.WithIncomingResponse), the values found in
+// the returned header and trailer metadata are stored in it. In fail-fast
+// mode, calls with too little time left fail with DeadlineExceeded without
+// being sent. The deadline of the call is reduced by the configured
+// Reservation.
 func UnaryClientIntercept(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
-	if kvs := getKeyValues(ctx); kvs != nil {
-		ctx = metadata.AppendToOutgoingContext(ctx, kvs...)
+	return unaryClientInterceptor(ctx, method, req, reply, cc, invoker, opts...)
+}
+
+var unaryClientInterceptor = NewUnaryClientInterceptor(netcontext.Default())
+
+// NewUnaryClientInterceptor creates an interceptor that works as
+// UnaryClientIntercept, but uses the given Propagator and options.
+func NewUnaryClientInterceptor(p *netcontext.Propagator, opts ...Option) grpc.UnaryClientInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		if err := p.CheckOutgoing(ctx); err != nil {
+			return status.Error(codes.DeadlineExceeded, err.Error())
+		}
+		ctx, cancel := reserve(ctx, o.reservation(p, method))
+		if cancel != nil {
+			defer cancel()
+		}
+		res := netcontext.IncomingResponse(ctx)
+		ctx = outgoing(p, ctx, o)
+		if res == nil {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		var header, trailer metadata.MD
+		opts = append(opts, grpc.Header(&header), grpc.Trailer(&trailer))
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		decodeResponse(p, res, metadata.Join(header, trailer), o)
+		return err
 	}
-	return invoker(ctx, method, req, reply, cc, opts...)
 }
 
-func getKeyValues(ctx context.Context) []string {
-	var kvs []string
-	for _, e := range netcontext.Entries() {
-		v := ctx.Value(e.CtxKey())
-		if v != nil {
-			kvs = append(kvs, metadataKey(e), e.Marshal(v))
+// StreamClientInterceptor intercepts an outgoing stream, adding metadata keys
+// for the configured context values and deadline. If the context holds an
+// incoming Response, the values found in the returned header and trailer
+// metadata are stored in it once the stream ends. The deadline of the stream
+// is reduced by the configured Reservation; its resources are released when
+// the stream ends or the context is done.
+func StreamClientInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamClientInterceptor(ctx, desc, cc, method, streamer, opts...)
+}
+
+var streamClientInterceptor = NewStreamClientInterceptor(netcontext.Default())
+
+// NewStreamClientInterceptor creates an interceptor that works as
+// StreamClientInterceptor, but uses the given Propagator and options.
+func NewStreamClientInterceptor(p *netcontext.Propagator, opts ...Option) grpc.StreamClientInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		if err := p.CheckOutgoing(ctx); err != nil {
+			return nil, status.Error(codes.DeadlineExceeded, err.Error())
+		}
+		parent := ctx
+		ctx, cancel := reserve(ctx, o.reservation(p, method))
+		res := netcontext.IncomingResponse(ctx)
+		ctx = outgoing(p, ctx, o)
+		cs, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			if cancel != nil {
+				cancel()
+			}
+			return nil, err
+		}
+		if release := cancel; release != nil {
+			// Streams are not always read to the end.
+			stop := context.AfterFunc(parent, release)
+			cancel = func() {
+				stop()
+				release()
+			}
 		}
+		if res == nil && cancel == nil {
+			return cs, nil
+		}
+		return &clientStream{ClientStream: cs, p: p, o: o, desc: desc, res: res, cancel: cancel}, nil
+	}
+}
+
+// A clientStream wraps a grpc.ClientStream to collect the response values
+// and release the context when the stream ends.
+type clientStream struct {
+	grpc.ClientStream
+	p      *netcontext.Propagator
+	o      *options
+	desc   *grpc.StreamDesc
+	res    *netcontext.Response
+	cancel context.CancelFunc
+	once   sync.Once
+}
+
+// RecvMsg receives a message. The stream ends when an error (including
+// io.EOF) is returned, or after the single response of a stream without
+// server streaming (as received by CloseAndRecv).
+func (s *clientStream) RecvMsg(m any) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil || !s.desc.ServerStreams {
+		s.finish()
 	}
-	if e, ok := netcontext.Deadline(); ok {
-		if t, ok := ctx.Deadline(); ok {
-			kvs = append(kvs, metadataKey(e), e.Marshal(t))
+	return err
+}
+
+// finish collects the response values and releases the context, once.
+func (s *clientStream) finish() {
+	s.once.Do(func() {
+		if s.res != nil {
+			header, _ := s.Header()
+			decodeResponse(s.p, s.res, metadata.Join(header, s.Trailer()), s.o)
+		}
+		if s.cancel != nil {
+			s.cancel()
 		}
+	})
+}
+
+// reserve shortens the deadline of the context by the Reservation. If there
+// is nothing to reserve, the context is returned unchanged with a nil
+// cancellation function.
+func reserve(ctx context.Context, r netcontext.Reservation) (context.Context, context.CancelFunc) {
+	t, ok := ctx.Deadline()
+	if !ok || r == (netcontext.Reservation{}) {
+		return ctx, nil
+	}
+	return context.WithDeadline(ctx, r.Apply(t))
+}
+
+// outgoing adds the context values and deadline to the outgoing metadata.
+func outgoing(p *netcontext.Propagator, ctx context.Context, o *options) context.Context {
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		md = metadata.MD{}
 	}
-	return kvs
+	cfg := o.carrierConfig(p)
+	p.Encode(MetadataCarrier(md), cfg, ctx.Value)
+	if !o.noDeadlineMetadata {
+		t, _ := ctx.Deadline()
+		p.EncodeDeadline(MetadataCarrier(md), cfg, t)
+	}
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+func decodeResponse(p *netcontext.Propagator, res *netcontext.Response, md metadata.MD, o *options) {
+	_ = p.Decode(MetadataCarrier(md), o.carrierConfig(p), true, func(e netcontext.Entry, a any) {
+		res.Set(e.CtxKey(), a)
+	})
 }
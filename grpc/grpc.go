@@ -2,6 +2,9 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"maps"
+	"slices"
 	"time"
 
 	"google.golang.org/grpc/metadata"
@@ -9,32 +12,75 @@ import (
 	"github.com/HayoVanLoon/go-netcontext"
 )
 
+// A MetadataCarrier adapts metadata.MD to a netcontext.Carrier.
+type MetadataCarrier metadata.MD
+
+func (c MetadataCarrier) Get(key string) []string {
+	return metadata.MD(c).Get(key)
+}
+
+func (c MetadataCarrier) Set(key string, values ...string) {
+	if len(values) == 0 {
+		metadata.MD(c).Delete(key)
+		return
+	}
+	metadata.MD(c).Set(key, values...)
+}
+
+func (c MetadataCarrier) Keys() []string {
+	return slices.Collect(maps.Keys(c))
+}
+
 // CopyDeadline searches for the deadline in the metadata and returns an
 // updated context with a cancellation function. If the headers do not include
 // the deadline value, the context is returned unchanged and the cancellation
-// function will be nil.
+// function will be nil. When the context already has a deadline, the earliest
+// one applies.
 func CopyDeadline(ctx context.Context) (context.Context, context.CancelFunc) {
-	e, ok := netcontext.Deadline()
-	if !ok {
-		return ctx, nil
+	ctx, cancel, _ := CopyDeadlineE(ctx)
+	return ctx, cancel
+}
+
+// CopyDeadlineE works as CopyDeadline, but also returns the error encountered
+// when the deadline could not be parsed.
+func CopyDeadlineE(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	return copyDeadline(netcontext.Default(), ctx, newOptions(nil), true)
+}
+
+func copyDeadline(p *netcontext.Propagator, ctx context.Context, o *options, trusted bool) (context.Context, context.CancelFunc, error) {
+	native, hasNative := ctx.Deadline()
+	if hasNative && o.deadlinePolicy == PreferNativeDeadline {
+		return ctx, nil, nil
 	}
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		return ctx, nil
+		return ctx, nil, nil
 	}
-	vs := md.Get(metadataKey(e))
-	if len(vs) == 0 {
-		return ctx, nil
+	t, ok, err := p.DecodeDeadline(MetadataCarrier(md), o.carrierConfig(p), trusted)
+	if !ok {
+		return ctx, nil, err
 	}
-	var t time.Time
-	if err := e.Unmarshal(vs[0], &t); err != nil {
-		netcontext.Log("error parsing deadline header: %s", err.Error())
-		return ctx, nil
+	var cancel context.CancelFunc
+	if hasNative && o.deadlinePolicy == PreferNetcontextDeadline && t.After(native) {
+		ctx, cancel = withLaterDeadline(ctx, t)
+	} else {
+		ctx, cancel = context.WithDeadline(ctx, t)
 	}
-	return context.WithDeadline(ctx, t)
+	return ctx, cancel, nil
 }
 
-func metadataKey(e netcontext.Entry) string {
-	key := e.StringKey()
-	return netcontext.GRPCMetadataPrefix() + key
+// withLaterDeadline returns a context with a deadline that may lie beyond
+// that of its parent. The parent's values are kept and cancellation of the
+// parent is still propagated, unless it was caused by its deadline.
+func withLaterDeadline(parent context.Context, t time.Time) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithDeadline(context.WithoutCancel(parent), t)
+	stop := context.AfterFunc(parent, func() {
+		if !errors.Is(parent.Err(), context.DeadlineExceeded) {
+			cancel()
+		}
+	})
+	return ctx, func() {
+		stop()
+		cancel()
+	}
 }
@@ -0,0 +1,162 @@
+package grpc
+
+import (
+	"context"
+	"sync/atomic"
+
+	"google.golang.org/grpc/peer"
+
+	"github.com/HayoVanLoon/go-netcontext"
+)
+
+// A DeadlinePolicy determines how a server interceptor reconciles the
+// deadline propagated natively by gRPC (via 'grpc-timeout') with the one
+// propagated in the netcontext metadata.
+type DeadlinePolicy int
+
+const (
+	// EarliestDeadline uses the earliest of both deadlines. This is the
+	// default.
+	EarliestDeadline DeadlinePolicy = iota
+	// PreferNativeDeadline uses the native gRPC deadline when present and
+	// only falls back to the netcontext deadline when it is absent.
+	PreferNativeDeadline
+	// PreferNetcontextDeadline uses the netcontext deadline when present,
+	// even when it is later than the native deadline. The native deadline
+	// then no longer cancels the handler; cancellation by the caller still
+	// does.
+	PreferNetcontextDeadline
+)
+
+// An Option configures an interceptor.
+type Option func(*options)
+
+// A TrustFunc reports whether a peer is trusted. The peer is nil when it is
+// unknown.
+type TrustFunc func(p *peer.Peer) bool
+
+// A MethodBoundsFunc returns the deadline bounds for a method, overriding
+// those of the Propagator. It returns false to keep the Propagator's bounds.
+type MethodBoundsFunc func(fullMethod string) (netcontext.DeadlineBounds, bool)
+
+// A ReservationFunc returns the Reservation for an outgoing call, overriding
+// that of the Propagator. It returns false to keep the Propagator's
+// Reservation.
+type ReservationFunc func(fullMethod string) (netcontext.Reservation, bool)
+
+type options struct {
+	deadlinePolicy     DeadlinePolicy
+	noDeadlineMetadata bool
+	format             netcontext.Format
+	trust              TrustFunc
+	methodBounds       MethodBoundsFunc
+	reservations       ReservationFunc
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithDeadlinePolicy sets the policy server interceptors use to reconcile
+// native and netcontext deadlines.
+func WithDeadlinePolicy(policy DeadlinePolicy) Option {
+	return func(o *options) {
+		o.deadlinePolicy = policy
+	}
+}
+
+// WithoutDeadlineMetadata stops client interceptors from adding the deadline
+// to the outgoing metadata, leaving its propagation to gRPC.
+func WithoutDeadlineMetadata() Option {
+	return func(o *options) {
+		o.noDeadlineMetadata = true
+	}
+}
+
+// WithFormat sets the format used for writing and reading context values.
+func WithFormat(f netcontext.Format) Option {
+	return func(o *options) {
+		o.format = f
+	}
+}
+
+// carrierConfig returns the configuration for metadata carriers.
+func (o *options) carrierConfig(p *netcontext.Propagator) netcontext.CarrierConfig {
+	return netcontext.CarrierConfig{Prefix: p.GRPCMetadataPrefix(), Format: o.format, Binary: true}
+}
+
+// WithTrust sets the function server interceptors use to decide whether the
+// peer is trusted. Values with an OnlyFromTrusted policy from untrusted peers
+// are dropped. Without it, no peer is trusted.
+func WithTrust(f TrustFunc) Option {
+	return func(o *options) {
+		o.trust = f
+	}
+}
+
+// defaultTrust holds the TrustFunc set by SetTrust.
+var defaultTrust atomic.Pointer[TrustFunc]
+
+// SetTrust sets the function UnaryServerInterceptor and
+// StreamServerInterceptor use to decide whether the peer is trusted. It takes
+// effect immediately. Without it, no peer is trusted.
+func SetTrust(f TrustFunc) {
+	defaultTrust.Store(&f)
+}
+
+// trustDefault is the TrustFunc of the default server interceptors. It
+// defers to the one set by SetTrust.
+func trustDefault(p *peer.Peer) bool {
+	f := defaultTrust.Load()
+	return f != nil && *f != nil && (*f)(p)
+}
+
+// trusted reports whether the peer in the context is trusted. Without a
+// TrustFunc, it is not.
+func (o *options) trusted(ctx context.Context) bool {
+	if o.trust == nil {
+		return false
+	}
+	p, _ := peer.FromContext(ctx)
+	return o.trust(p)
+}
+
+// WithMethodDeadlineBounds sets per-method deadline bounds for server
+// interceptors.
+func WithMethodDeadlineBounds(f MethodBoundsFunc) Option {
+	return func(o *options) {
+		o.methodBounds = f
+	}
+}
+
+// deadlineBounds returns the deadline bounds for the method.
+func (o *options) deadlineBounds(p *netcontext.Propagator, fullMethod string) netcontext.DeadlineBounds {
+	if o.methodBounds != nil {
+		if b, ok := o.methodBounds(fullMethod); ok {
+			return b
+		}
+	}
+	return p.DeadlineBounds()
+}
+
+// WithReservations sets per-method deadline reservations for client
+// interceptors.
+func WithReservations(f ReservationFunc) Option {
+	return func(o *options) {
+		o.reservations = f
+	}
+}
+
+// reservation returns the Reservation for the method.
+func (o *options) reservation(p *netcontext.Propagator, fullMethod string) netcontext.Reservation {
+	if o.reservations != nil {
+		if r, ok := o.reservations(fullMethod); ok {
+			return r
+		}
+	}
+	return p.Reservation()
+}
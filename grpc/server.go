@@ -2,45 +2,153 @@ package grpc
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/HayoVanLoon/go-netcontext"
 )
 
 // UnaryServerInterceptor extracts configured values from the incoming metadata
 // and stores them in the context. Sets a deadline (and handles its
-// cancellation) when one is found. Does not process outgoing metadata.
-func UnaryServerInterceptor(ctx context.Context, r any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
-	ctx = ExtractMetadata(ctx)
-	ctx, cancel := CopyDeadline(ctx)
-	if cancel != nil {
-		defer cancel()
+// cancellation) when one is found, applying the configured deadline bounds;
+// calls with too little time left (or, in fail-fast mode, an expired
+// deadline) are rejected with DeadlineExceeded. In strict mode, calls with
+// values that cannot be parsed are rejected with InvalidArgument. Values
+// marked with netcontext.Respond are sent back in the trailer metadata.
+func UnaryServerInterceptor(ctx context.Context, r any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	return unaryServerInterceptor(ctx, r, info, handler)
+}
+
+var unaryServerInterceptor = NewUnaryServerInterceptor(netcontext.Default(), WithTrust(trustDefault))
+
+// NewUnaryServerInterceptor creates an interceptor that works as
+// UnaryServerInterceptor, but uses the given Propagator and options.
+func NewUnaryServerInterceptor(p *netcontext.Propagator, opts ...Option) grpc.UnaryServerInterceptor {
+	o := newOptions(opts)
+	return func(ctx context.Context, r any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		trusted := o.trusted(ctx)
+		ctx, err := extractMetadata(p, ctx, o, trusted)
+		ctx, cancel, err2 := copyDeadline(p, ctx, o, trusted)
+		if cancel != nil {
+			defer cancel()
+		}
+		if err = errors.Join(err, err2); err != nil && p.Strict() {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		ctx, cancel, err = o.deadlineBounds(p, info.FullMethod).Apply(ctx)
+		if err != nil {
+			return nil, status.Error(codes.DeadlineExceeded, err.Error())
+		}
+		if cancel != nil {
+			defer cancel()
+		}
+		if err := p.CheckIncoming(ctx); err != nil {
+			return nil, status.Error(codes.DeadlineExceeded, err.Error())
+		}
+		ctx, res := netcontext.WithOutgoingResponse(ctx)
+		resp, err := handler(ctx, r)
+		if md := encodeResponse(p, res, o); md != nil {
+			if err := grpc.SetTrailer(ctx, md); err != nil {
+				p.Report(fmt.Errorf("error setting trailer: %w", err))
+			}
+		}
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor extracts configured values from the incoming
+// metadata and stores them in the stream context. Sets a deadline (and handles
+// its cancellation when the stream ends) when one is found, applying the
+// configured deadline bounds. Values marked with netcontext.Respond are sent
+// back in the trailer metadata.
+func StreamServerInterceptor(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	return streamServerInterceptor(srv, ss, info, handler)
+}
+
+var streamServerInterceptor = NewStreamServerInterceptor(netcontext.Default(), WithTrust(trustDefault))
+
+// NewStreamServerInterceptor creates an interceptor that works as
+// StreamServerInterceptor, but uses the given Propagator and options.
+func NewStreamServerInterceptor(p *netcontext.Propagator, opts ...Option) grpc.StreamServerInterceptor {
+	o := newOptions(opts)
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		trusted := o.trusted(ss.Context())
+		ctx, err := extractMetadata(p, ss.Context(), o, trusted)
+		ctx, cancel, err2 := copyDeadline(p, ctx, o, trusted)
+		if cancel != nil {
+			defer cancel()
+		}
+		if err = errors.Join(err, err2); err != nil && p.Strict() {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		ctx, cancel, err = o.deadlineBounds(p, info.FullMethod).Apply(ctx)
+		if err != nil {
+			return status.Error(codes.DeadlineExceeded, err.Error())
+		}
+		if cancel != nil {
+			defer cancel()
+		}
+		if err := p.CheckIncoming(ctx); err != nil {
+			return status.Error(codes.DeadlineExceeded, err.Error())
+		}
+		ctx, res := netcontext.WithOutgoingResponse(ctx)
+		err = handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+		if md := encodeResponse(p, res, o); md != nil {
+			ss.SetTrailer(md)
+		}
+		return err
 	}
-	return handler(ctx, r)
+}
+
+// A serverStream wraps a grpc.ServerStream to replace its context.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the enriched stream context.
+func (s *serverStream) Context() context.Context {
+	return s.ctx
 }
 
 // ExtractMetadata extracts configured values from the metadata and stores them
-// in the returned context.
+// in the returned context. The metadata is considered to come from a trusted
+// peer.
 func ExtractMetadata(ctx context.Context) context.Context {
+	ctx, _ = ExtractMetadataE(ctx)
+	return ctx
+}
+
+// ExtractMetadataE works as ExtractMetadata, but also returns the errors
+// encountered. Values that could not be parsed are reported as
+// *netcontext.ParseError.
+func ExtractMetadataE(ctx context.Context) (context.Context, error) {
+	return extractMetadata(netcontext.Default(), ctx, newOptions(nil), true)
+}
+
+func extractMetadata(p *netcontext.Propagator, ctx context.Context, o *options, trusted bool) (context.Context, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		return ctx
+		return ctx, nil
 	}
-	for _, e := range netcontext.Entries() {
-		vs := md.Get(metadataKey(e))
-		if len(vs) == 0 {
-			vs = md.Get(metadataKey(e))
-		}
-		if len(vs) > 0 {
-			var a any
-			if err := e.Unmarshal(vs[0], &a); err != nil {
-				netcontext.Log("error parsing %q: %s", e.StringKey(), err.Error())
-				continue
-			}
-			ctx = context.WithValue(ctx, e.CtxKey(), a)
-		}
+	err := p.Decode(MetadataCarrier(md), o.carrierConfig(p), trusted, func(e netcontext.Entry, a any) {
+		ctx = context.WithValue(ctx, e.CtxKey(), a)
+	})
+	return ctx, err
+}
+
+// encodeResponse returns the metadata for the response values, or nil if
+// there are none.
+func encodeResponse(p *netcontext.Propagator, res *netcontext.Response, o *options) metadata.MD {
+	if res.Len() == 0 {
+		return nil
 	}
-	return ctx
+	md := metadata.MD{}
+	p.Encode(MetadataCarrier(md), o.carrierConfig(p), res.Value)
+	return md
 }
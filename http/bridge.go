@@ -0,0 +1,42 @@
+package http
+
+import (
+	"net/http"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/HayoVanLoon/go-netcontext"
+)
+
+// HeaderFromMetadata translates the configured values and deadline in the
+// gRPC metadata to HTTP headers, replacing the gRPC metadata prefix with the
+// HTTP header prefix. Values are copied as-is, without being parsed, but
+// binary values are base64url-encoded. Other metadata is ignored. As when
+// extracting, values not accepted from the sender (according to trusted) are
+// skipped; entries that are not sent to peers are skipped as well.
+func HeaderFromMetadata(md metadata.MD, trusted bool) http.Header {
+	return headerFromMetadata(netcontext.Default(), md, trusted)
+}
+
+func headerFromMetadata(p *netcontext.Propagator, md metadata.MD, trusted bool) http.Header {
+	h := http.Header{}
+	from := netcontext.CarrierConfig{Prefix: p.GRPCMetadataPrefix(), Binary: true}
+	to := newOptions(nil).carrierConfig(p)
+	cp := func(e netcontext.Entry) {
+		for _, v := range md.Get(from.Key(e)) {
+			if e.Binary() {
+				v = netcontext.EncodeBinary([]byte(v))
+			}
+			h.Add(to.Key(e), v)
+		}
+	}
+	for _, e := range p.Entries() {
+		if e.Policy().Accepts(trusted) && e.Policy().Sends() {
+			cp(e)
+		}
+	}
+	if e, ok := p.Deadline(); ok && p.AcceptsDeadline(trusted) {
+		cp(e)
+	}
+	return h
+}
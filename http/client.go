@@ -1,7 +1,6 @@
 package http
 
 import (
-	"context"
 	"net/http"
 
 	"github.com/HayoVanLoon/go-netcontext"
@@ -14,41 +13,52 @@ func Client() *http.Client {
 
 // WrapClient wraps a standard http.Client.
 func WrapClient(c *http.Client) *http.Client {
-	base := c.Transport
+	c.Transport = NewRoundTripper(netcontext.Default(), c.Transport)
+	return c
+}
+
+// NewRoundTripper creates a ContextRoundTripper using the given Propagator
+// and options. If base is nil, http.DefaultTransport will be used.
+func NewRoundTripper(p *netcontext.Propagator, base http.RoundTripper, opts ...Option) ContextRoundTripper {
 	if base == nil {
 		base = http.DefaultTransport
 	}
-	c.Transport = ContextRoundTripper{base: base}
-	return c
+	return ContextRoundTripper{p: p, base: base, o: newOptions(opts)}
 }
 
 // A ContextRoundTripper propagates the configured context values in an
-// outgoing HTTP request. It does not handle returned response headers.
+// outgoing HTTP request. If the request context holds an incoming Response
+// (see netcontext.WithIncomingResponse), the values found in the response
+// headers are stored in it. The propagated deadline is reduced by the
+// configured Reservation. Unlike the gRPC client interceptors, this only
+// affects the deadline header; the request itself keeps the deadline of its
+// context, as the response body may still be read after RoundTrip returns. In
+// fail-fast mode, requests with too little time left are not sent.
 type ContextRoundTripper struct {
+	p    *netcontext.Propagator
 	base http.RoundTripper
+	o    *options
 }
 
 func (c ContextRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
-	for k, vs := range c.createHeaders(r.Context()) {
-		for _, v := range vs {
-			r.Header.Add(k, v)
+	if err := c.p.CheckOutgoing(r.Context()); err != nil {
+		if r.Body != nil {
+			_ = r.Body.Close()
 		}
+		return nil, err
 	}
-	if e, ok := netcontext.Deadline(); ok {
-		if t, ok := r.Context().Deadline(); ok {
-			r.Header.Add(headerKey(e), e.Marshal(t))
-		}
+	cfg := c.o.carrierConfig(c.p)
+	c.p.Encode(HeaderCarrier(r.Header), cfg, r.Context().Value)
+	t, ok := r.Context().Deadline()
+	if ok {
+		t = c.o.reservation(c.p, r).Apply(t)
 	}
-	return c.base.RoundTrip(r)
-}
-
-func (c ContextRoundTripper) createHeaders(ctx context.Context) http.Header {
-	h := http.Header{}
-	for _, e := range netcontext.Entries() {
-		v := ctx.Value(e.CtxKey())
-		if v != nil {
-			h.Add(headerKey(e), e.Marshal(v))
-		}
+	c.p.EncodeDeadline(HeaderCarrier(r.Header), cfg, t)
+	resp, err := c.base.RoundTrip(r)
+	if res := netcontext.IncomingResponse(r.Context()); res != nil && resp != nil {
+		_ = c.p.Decode(HeaderCarrier(resp.Header), cfg, true, func(e netcontext.Entry, a any) {
+			res.Set(e.CtxKey(), a)
+		})
 	}
-	return h
+	return resp, err
 }
@@ -2,37 +2,62 @@ package http
 
 import (
 	"context"
+	"maps"
 	"net/http"
-	"time"
+	"slices"
 
 	"github.com/HayoVanLoon/go-netcontext"
 )
 
+// A HeaderCarrier adapts http.Header to a netcontext.Carrier.
+type HeaderCarrier http.Header
+
+func (c HeaderCarrier) Get(key string) []string {
+	return http.Header(c).Values(key)
+}
+
+func (c HeaderCarrier) Set(key string, values ...string) {
+	http.Header(c).Del(key)
+	for _, v := range values {
+		http.Header(c).Add(key, v)
+	}
+}
+
+func (c HeaderCarrier) Keys() []string {
+	return slices.Collect(maps.Keys(c))
+}
+
 // Extract extracts the values from the headers (or trailers) and returns a new
 // context with the values found. This method will never set a deadline on the
-// context.
+// context. The headers are considered to come from a trusted peer.
 func Extract(ctx context.Context, h http.Header) context.Context {
-	for _, e := range netcontext.Entries() {
-		v := h.Get(headerKey(e))
-		if v == "" {
-			continue
-		}
-		var a any
-		if err := e.Unmarshal(v, &a); err != nil {
-			netcontext.Log("could not parse value for key %q: %v", e.StringKey(), v)
-			continue
-		}
-		ctx = context.WithValue(ctx, e.CtxKey(), a)
-	}
+	ctx, _ = ExtractE(ctx, h)
 	return ctx
 }
 
+// ExtractE works as Extract, but also returns the errors encountered. Values
+// that could not be parsed are reported as *netcontext.ParseError.
+func ExtractE(ctx context.Context, h http.Header) (context.Context, error) {
+	return extract(netcontext.Default(), ctx, h, newOptions(nil), true)
+}
+
+func extract(p *netcontext.Propagator, ctx context.Context, h http.Header, o *options, trusted bool) (context.Context, error) {
+	err := p.Decode(HeaderCarrier(h), o.carrierConfig(p), trusted, func(e netcontext.Entry, a any) {
+		ctx = context.WithValue(ctx, e.CtxKey(), a)
+	})
+	return ctx, err
+}
+
 // ExtractWithDeadline works as Extract, but will set a deadline if one is
 // found in the headers. In that case (only), the cancellation function will be
 // nil.
 func ExtractWithDeadline(ctx context.Context, h http.Header) (context.Context, context.CancelFunc) {
-	ctx = Extract(ctx, h)
-	return CopyDeadline(ctx, h)
+	ctx, cancel, _ := extractWithDeadline(netcontext.Default(), ctx, h, newOptions(nil), true)
+	return ctx, cancel
+}
+
+func extractWithDeadline(p *netcontext.Propagator, ctx context.Context, h http.Header, o *options, trusted bool) (context.Context, context.CancelFunc, error) {
+	return p.Extract(ctx, HeaderCarrier(h), o.carrierConfig(p), trusted)
 }
 
 // CopyDeadline searches for the deadline in the headers and returns an updated
@@ -40,22 +65,18 @@ func ExtractWithDeadline(ctx context.Context, h http.Header) (context.Context, c
 // deadline value, the context is returned unchanged and the cancellation
 // function will be nil.
 func CopyDeadline(ctx context.Context, h http.Header) (context.Context, context.CancelFunc) {
-	e, ok := netcontext.Deadline()
-	if !ok {
-		return ctx, nil
-	}
-	s := h.Get(headerKey(e))
-	if s == "" {
-		return ctx, nil
-	}
-	var t time.Time
-	if err := e.Unmarshal(s, &t); err != nil {
-		netcontext.Log("error parsing deadline header: %s", err.Error())
-		return ctx, nil
-	}
-	return context.WithDeadline(ctx, t)
+	ctx, cancel, _ := CopyDeadlineE(ctx, h)
+	return ctx, cancel
 }
 
-func headerKey(e netcontext.Entry) string {
-	return netcontext.HTTPHeaderPrefix() + e.StringKey()
+// CopyDeadlineE works as CopyDeadline, but also returns the error encountered
+// when the deadline could not be parsed.
+func CopyDeadlineE(ctx context.Context, h http.Header) (context.Context, context.CancelFunc, error) {
+	p := netcontext.Default()
+	t, ok, err := p.DecodeDeadline(HeaderCarrier(h), newOptions(nil).carrierConfig(p), true)
+	if !ok {
+		return ctx, nil, err
+	}
+	ctx, cancel := context.WithDeadline(ctx, t)
+	return ctx, cancel, nil
 }
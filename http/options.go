@@ -0,0 +1,136 @@
+package http
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/HayoVanLoon/go-netcontext"
+)
+
+// An Option configures a client or handler wrapper.
+type Option func(*options)
+
+// A TrustFunc reports whether the sender of a request is trusted.
+type TrustFunc func(r *http.Request) bool
+
+// A RouteBoundsFunc returns the deadline bounds for a request, overriding
+// those of the Propagator. It returns false to keep the Propagator's bounds.
+type RouteBoundsFunc func(r *http.Request) (netcontext.DeadlineBounds, bool)
+
+// A ReservationFunc returns the Reservation for an outgoing request,
+// overriding that of the Propagator. It returns false to keep the
+// Propagator's Reservation.
+type ReservationFunc func(r *http.Request) (netcontext.Reservation, bool)
+
+type options struct {
+	format         netcontext.Format
+	responseValues bool
+	trust          TrustFunc
+	routeBounds    RouteBoundsFunc
+	failFastStatus int
+	reservations   ReservationFunc
+}
+
+func newOptions(opts []Option) *options {
+	o := &options{failFastStatus: http.StatusGatewayTimeout}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithFormat sets the format used for writing and reading context values.
+func WithFormat(f netcontext.Format) Option {
+	return func(o *options) {
+		o.format = f
+	}
+}
+
+// carrierConfig returns the configuration for header carriers.
+func (o *options) carrierConfig(p *netcontext.Propagator) netcontext.CarrierConfig {
+	return netcontext.CarrierConfig{Prefix: p.HTTPHeaderPrefix(), Format: o.format}
+}
+
+// WithResponseValues makes handler wrappers send values marked with
+// netcontext.Respond back to the caller in the response headers. This wraps
+// the http.ResponseWriter.
+func WithResponseValues() Option {
+	return func(o *options) {
+		o.responseValues = true
+	}
+}
+
+// WithTrust sets the function handler wrappers use to decide whether the
+// sender of a request is trusted. Values with an OnlyFromTrusted policy from
+// untrusted senders are dropped. Without it, no sender is trusted.
+func WithTrust(f TrustFunc) Option {
+	return func(o *options) {
+		o.trust = f
+	}
+}
+
+// defaultTrust holds the TrustFunc set by SetTrust.
+var defaultTrust atomic.Pointer[TrustFunc]
+
+// SetTrust sets the function WrapHandler and WrapHandlerFunc use to decide
+// whether the sender of a request is trusted. It takes effect immediately,
+// also for handlers wrapped before. Without it, no sender is trusted.
+func SetTrust(f TrustFunc) {
+	defaultTrust.Store(&f)
+}
+
+// trustDefault is the TrustFunc of the default handler wrappers. It defers to
+// the one set by SetTrust.
+func trustDefault(r *http.Request) bool {
+	f := defaultTrust.Load()
+	return f != nil && *f != nil && (*f)(r)
+}
+
+// trusted reports whether the sender of the request is trusted. Without a
+// TrustFunc, it is not.
+func (o *options) trusted(r *http.Request) bool {
+	return o.trust != nil && o.trust(r)
+}
+
+// WithRouteDeadlineBounds sets per-route deadline bounds for handler wrappers.
+func WithRouteDeadlineBounds(f RouteBoundsFunc) Option {
+	return func(o *options) {
+		o.routeBounds = f
+	}
+}
+
+// deadlineBounds returns the deadline bounds for the request.
+func (o *options) deadlineBounds(p *netcontext.Propagator, r *http.Request) netcontext.DeadlineBounds {
+	if o.routeBounds != nil {
+		if b, ok := o.routeBounds(r); ok {
+			return b
+		}
+	}
+	return p.DeadlineBounds()
+}
+
+// WithFailFastStatus sets the status code handler wrappers respond with when
+// rejecting a request in fail-fast mode. The default is 504.
+func WithFailFastStatus(code int) Option {
+	return func(o *options) {
+		o.failFastStatus = code
+	}
+}
+
+// WithReservations sets per-request (i.e. per-host) deadline reservations for
+// clients.
+func WithReservations(f ReservationFunc) Option {
+	return func(o *options) {
+		o.reservations = f
+	}
+}
+
+// reservation returns the Reservation for the request.
+func (o *options) reservation(p *netcontext.Propagator, r *http.Request) netcontext.Reservation {
+	if o.reservations != nil {
+		if res, ok := o.reservations(r); ok {
+			return res
+		}
+	}
+	return p.Reservation()
+}
@@ -2,25 +2,111 @@ package http
 
 import (
 	"net/http"
+
+	"github.com/HayoVanLoon/go-netcontext"
 )
 
 // WrapHandler wraps an http.Handler, adding configured values to the incoming
-// context. Sets a deadline (and handles its cancellation) when one is found.
-// Does not process outgoing response headers.
+// context. Sets a deadline (and handles its cancellation) when one is found,
+// applying the configured deadline bounds; requests with too little time left
+// are rejected with status 504. In fail-fast mode, requests with an expired
+// deadline are rejected as well. In strict mode, requests with values that
+// cannot be parsed are rejected with status 400. Does not process outgoing
+// response headers.
 func WrapHandler(h http.Handler) http.Handler {
 	return WrapHandlerFunc(h.ServeHTTP)
 }
 
 // WrapHandlerFunc wraps an http.HandlerFunc, adding configured values to the
 // incoming context. Sets a deadline (and handles its cancellation) when one is
-// found. Does not process outgoing response headers.
+// found, applying the configured deadline bounds; requests with too little
+// time left are rejected with status 504. Does not process outgoing response
+// headers.
 func WrapHandlerFunc(h http.HandlerFunc) http.HandlerFunc {
+	return NewHandlerFunc(netcontext.Default(), h, WithTrust(trustDefault))
+}
+
+// NewHandler works as WrapHandler, but uses the given Propagator and options.
+func NewHandler(p *netcontext.Propagator, h http.Handler, opts ...Option) http.Handler {
+	return NewHandlerFunc(p, h.ServeHTTP, opts...)
+}
+
+// NewHandlerFunc works as WrapHandlerFunc, but uses the given Propagator and
+// options.
+func NewHandlerFunc(p *netcontext.Propagator, h http.HandlerFunc, opts ...Option) http.HandlerFunc {
+	o := newOptions(opts)
 	return func(w http.ResponseWriter, r *http.Request) {
-		ctx, cancel := ExtractWithDeadline(r.Context(), r.Header)
+		ctx, cancel, err := extractWithDeadline(p, r.Context(), r.Header, o, o.trusted(r))
+		if cancel != nil {
+			defer cancel()
+		}
+		if err != nil && p.Strict() {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ctx, cancel, err = o.deadlineBounds(p, r).Apply(ctx)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusGatewayTimeout)
+			return
+		}
 		if cancel != nil {
 			defer cancel()
 		}
+		if err := p.CheckIncoming(ctx); err != nil {
+			http.Error(w, err.Error(), o.failFastStatus)
+			return
+		}
+		if o.responseValues {
+			var res *netcontext.Response
+			ctx, res = netcontext.WithOutgoingResponse(ctx)
+			rw := &responseWriter{ResponseWriter: w, p: p, o: o, res: res}
+			defer rw.writeValues()
+			w = rw
+		}
 		r = r.WithContext(ctx)
 		h(w, r)
 	}
 }
+
+// A responseWriter adds the outgoing response values to the headers just
+// before these are sent. Values set afterwards are not sent.
+type responseWriter struct {
+	http.ResponseWriter
+	p       *netcontext.Propagator
+	o       *options
+	res     *netcontext.Response
+	written bool
+}
+
+func (w *responseWriter) WriteHeader(code int) {
+	w.writeValues()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *responseWriter) Write(bs []byte) (int, error) {
+	w.writeValues()
+	return w.ResponseWriter.Write(bs)
+}
+
+func (w *responseWriter) Flush() {
+	w.writeValues()
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the wrapped http.ResponseWriter, for use by
+// http.ResponseController.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *responseWriter) writeValues() {
+	if w.written {
+		return
+	}
+	w.written = true
+	if w.res.Len() > 0 {
+		w.p.Encode(HeaderCarrier(w.Header()), w.o.carrierConfig(w.p), w.res.Value)
+	}
+}
@@ -0,0 +1,80 @@
+package netcontext
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// DefaultMaxJSONSize is the default maximum size of values of entries created
+// with JSONEntry, in bytes.
+const DefaultMaxJSONSize = 4096
+
+// JSONEntry creates an Entry for a context value of type T, marshalled as
+// compact JSON. Non-ASCII characters are escaped, so the result is safe to use
+// as a header value. Values are decoded strictly: unknown fields and trailing
+// data are rejected. The maximum size of the Entry is set to
+// DefaultMaxJSONSize; use WithMaxSize to change it.
+func JSONEntry[T any](ctxKey any, stringKey string) Entry {
+	parse := func(s string) (T, error) {
+		var v T
+		dec := json.NewDecoder(bytes.NewBufferString(s))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&v); err != nil {
+			return v, err
+		}
+		if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+			return v, fmt.Errorf("unexpected data after JSON value")
+		}
+		return v, nil
+	}
+	format := func(v T) string {
+		bs, err := json.Marshal(v)
+		if err != nil {
+			return ""
+		}
+		return asciiJSON(bs)
+	}
+	return EntryOf(ctxKey, stringKey, parse, format).WithMaxSize(DefaultMaxJSONSize)
+}
+
+// JSON adds an Entry for a context value of type T, marshalled as JSON (see
+// JSONEntry).
+func JSON[T any](ctxKey any, stringKey string) {
+	Add(JSONEntry[T](ctxKey, stringKey))
+}
+
+// asciiJSON escapes the non-ASCII characters (and DEL) in JSON. Outside of
+// strings, JSON only consists of ASCII characters, so the result is still
+// valid.
+func asciiJSON(bs []byte) string {
+	var buf bytes.Buffer
+	for len(bs) > 0 {
+		r, size := utf8.DecodeRune(bs)
+		bs = bs[size:]
+		if r < utf8.RuneSelf && r != 0x7f {
+			buf.WriteRune(r)
+			continue
+		}
+		if r1, r2 := utf16.EncodeRune(r); r1 != utf8.RuneError {
+			writeUnicodeEscape(&buf, r1)
+			r = r2
+		}
+		writeUnicodeEscape(&buf, r)
+	}
+	return buf.String()
+}
+
+func writeUnicodeEscape(buf *bytes.Buffer, r rune) {
+	s := strconv.FormatInt(int64(r), 16)
+	buf.WriteString(`\u`)
+	for i := len(s); i < 4; i++ {
+		buf.WriteByte('0')
+	}
+	buf.WriteString(s)
+}
@@ -0,0 +1,80 @@
+package netcontext
+
+import (
+	"context"
+)
+
+// EntryOf creates an Entry for a context value of type T. The parser function
+// is required. If the formatter function is not provided, DefaultToString will
+// be used.
+func EntryOf[T any](ctxKey any, stringKey string, parse func(s string) (T, error), format func(v T) string) Entry {
+	if parse == nil {
+		panic("parser function cannot be nil")
+	}
+	parseValue := func(s string) (any, error) {
+		v, err := parse(s)
+		if err != nil {
+			return nil, err
+		}
+		return v, nil
+	}
+	toString := func(a any) string {
+		v, ok := a.(T)
+		if !ok {
+			return ""
+		}
+		if format == nil {
+			return DefaultToString(v)
+		}
+		return format(v)
+	}
+	return NewEntry(ctxKey, stringKey, parseValue, toString)
+}
+
+// A Key provides type-safe access to a context value of type T.
+type Key[T any] struct {
+	entry Entry
+}
+
+// NewKey creates a Key backed by an Entry created with EntryOf. The Key is not
+// registered; add its Entry to a Propagator to propagate the value.
+func NewKey[T any](ctxKey any, stringKey string, parse func(s string) (T, error), format func(v T) string) Key[T] {
+	return Key[T]{entry: EntryOf(ctxKey, stringKey, parse, format)}
+}
+
+// Register creates a Key (see NewKey) and adds its Entry to the default
+// Propagator.
+func Register[T any](ctxKey any, stringKey string, parse func(s string) (T, error), format func(v T) string) Key[T] {
+	k := NewKey(ctxKey, stringKey, parse, format)
+	Add(k.entry)
+	return k
+}
+
+// Entry returns the Entry backing the Key.
+func (k Key[T]) Entry() Entry {
+	return k.entry
+}
+
+// From returns the value from the context. Returns false if it is absent or
+// not of type T.
+func (k Key[T]) From(ctx context.Context) (T, bool) {
+	v, ok := ctx.Value(k.entry.CtxKey()).(T)
+	return v, ok
+}
+
+// With returns a copy of the context holding the value.
+func (k Key[T]) With(ctx context.Context, v T) context.Context {
+	return context.WithValue(ctx, k.entry.CtxKey(), v)
+}
+
+// Respond marks the value to be sent back to the caller. See Respond.
+func (k Key[T]) Respond(ctx context.Context, v T) bool {
+	return Respond(ctx, k.entry.CtxKey(), v)
+}
+
+// FromResponse returns the value from the Response. Returns false if it is
+// absent or not of type T.
+func (k Key[T]) FromResponse(r *Response) (T, bool) {
+	v, ok := r.Value(k.entry.CtxKey()).(T)
+	return v, ok
+}
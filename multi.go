@@ -0,0 +1,190 @@
+package netcontext
+
+import (
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+type MultiParseFunc func(ss []string) (any, error)
+
+type MultiStringFunc func(a any) []string
+
+var mapKeyEscaper = strings.NewReplacer("%", "%25", "=", "%3D")
+
+// NewMultiEntry creates an Entry for a context value consisting of multiple
+// strings, like a slice. Over HTTP and gRPC, the strings are sent as repeated
+// header or metadata values. Where a single string is required (as in
+// baggage), they are joined with commas. Commas, percent signs and characters
+// that are not printable ASCII are percent-encoded; surrounding whitespace is
+// not preserved. Both functions are required.
+func NewMultiEntry(ctxKey any, stringKey string, parse MultiParseFunc, toStrings MultiStringFunc) Entry {
+	if parse == nil {
+		panic("parser function cannot be nil")
+	}
+	if toStrings == nil {
+		panic("stringer function cannot be nil")
+	}
+	parseValue := func(s string) (any, error) {
+		ss, err := splitValues(s)
+		if err != nil {
+			return nil, err
+		}
+		return parse(ss)
+	}
+	toString := func(a any) string {
+		return strings.Join(escapeValues(toStrings(a)), ",")
+	}
+	e := NewEntry(ctxKey, stringKey, parseValue, toString)
+	e.toStrings = toStrings
+	return e
+}
+
+// Multi reports whether the Entry is multi-valued.
+func (e Entry) Multi() bool {
+	return e.toStrings != nil
+}
+
+// MarshalValues marshals a value into the strings to send as separate header
+// or metadata values. A single-valued Entry always yields one string.
+func (e Entry) MarshalValues(a any) []string {
+	if e.toStrings == nil {
+		return []string{e.Marshal(a)}
+	}
+	return escapeValues(e.toStrings(a))
+}
+
+// UnmarshalValues unmarshalls the values of a header or metadata key into
+// 'a'. A single-valued Entry only uses the first value.
+func (e Entry) UnmarshalValues(ss []string, a any) error {
+	if len(ss) == 0 {
+		return fmt.Errorf("no values")
+	}
+	if e.toStrings == nil {
+		return e.Unmarshal(ss[0], a)
+	}
+	return e.Unmarshal(strings.Join(ss, ","), a)
+}
+
+func escapeValues(ss []string) []string {
+	out := make([]string, len(ss))
+	for i, s := range ss {
+		out[i] = escapeValue(s)
+	}
+	return out
+}
+
+// escapeValue percent-encodes commas, percent signs and characters that are
+// not printable ASCII.
+func escapeValue(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if isPrintable(c) && c != ',' && c != '%' {
+			sb.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&sb, "%%%02X", c)
+	}
+	return sb.String()
+}
+
+// splitValues splits a comma-separated list of escaped values. An empty
+// string yields no values.
+func splitValues(s string) ([]string, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	ss := strings.Split(s, ",")
+	for i := range ss {
+		v, err := url.PathUnescape(strings.TrimSpace(ss[i]))
+		if err != nil {
+			return nil, err
+		}
+		ss[i] = v
+	}
+	return ss, nil
+}
+
+// StringSliceEntry creates an Entry for a []string context value.
+func StringSliceEntry(ctxKey any, stringKey string) Entry {
+	parse := func(ss []string) (any, error) {
+		return ss, nil
+	}
+	toStrings := func(a any) []string {
+		ss, _ := a.([]string)
+		return ss
+	}
+	return NewMultiEntry(ctxKey, stringKey, parse, toStrings)
+}
+
+// StringSlice adds an Entry for a []string context value.
+func StringSlice(ctxKey any, stringKey string) {
+	Add(StringSliceEntry(ctxKey, stringKey))
+}
+
+// IntSliceEntry creates an Entry for an []int context value.
+func IntSliceEntry(ctxKey any, stringKey string) Entry {
+	parse := func(ss []string) (any, error) {
+		is := make([]int, len(ss))
+		for i, s := range ss {
+			v, err := strconv.Atoi(s)
+			if err != nil {
+				return nil, err
+			}
+			is[i] = v
+		}
+		return is, nil
+	}
+	toStrings := func(a any) []string {
+		is, _ := a.([]int)
+		ss := make([]string, len(is))
+		for i, v := range is {
+			ss[i] = strconv.Itoa(v)
+		}
+		return ss
+	}
+	return NewMultiEntry(ctxKey, stringKey, parse, toStrings)
+}
+
+// IntSlice adds an Entry for an []int context value.
+func IntSlice(ctxKey any, stringKey string) {
+	Add(IntSliceEntry(ctxKey, stringKey))
+}
+
+// StringMapEntry creates an Entry for a map[string]string context value. Each
+// key-value pair is sent as "key=value", ordered by key.
+func StringMapEntry(ctxKey any, stringKey string) Entry {
+	parse := func(ss []string) (any, error) {
+		m := make(map[string]string, len(ss))
+		for _, s := range ss {
+			k, v, ok := strings.Cut(s, "=")
+			if !ok {
+				return nil, fmt.Errorf("map element without value: %q", s)
+			}
+			k, err := url.PathUnescape(k)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = v
+		}
+		return m, nil
+	}
+	toStrings := func(a any) []string {
+		m, _ := a.(map[string]string)
+		ss := make([]string, 0, len(m))
+		for _, k := range slices.Sorted(maps.Keys(m)) {
+			ss = append(ss, mapKeyEscaper.Replace(k)+"="+m[k])
+		}
+		return ss
+	}
+	return NewMultiEntry(ctxKey, stringKey, parse, toStrings)
+}
+
+// StringMap adds an Entry for a map[string]string context value.
+func StringMap(ctxKey any, stringKey string) {
+	Add(StringMapEntry(ctxKey, stringKey))
+}
@@ -1,8 +1,11 @@
 package netcontext
 
 import (
+	"context"
+	"encoding"
 	"fmt"
 	"log"
+	"log/slog"
 	"reflect"
 	"strconv"
 	"time"
@@ -20,6 +23,38 @@ type Entry struct {
 
 	parseValue    ParseFunc
 	valueToString StringFunc
+
+	policy    Policy
+	sensitive bool
+	maxSize   int
+
+	// toStrings is set for multi-valued entries only.
+	toStrings MultiStringFunc
+
+	// parseBytes and toBytes are set for binary entries only.
+	parseBytes BytesParseFunc
+	toBytes    BytesFunc
+}
+
+// NewEntry creates an Entry with the given parameters. The string key must be
+// valid (see ValidateKey). The parser function is required. If the stringer
+// function is not provided, DefaultToString will be used.
+func NewEntry(ctxKey any, stringKey string, parse ParseFunc, toString StringFunc) Entry {
+	if err := ValidateKey(stringKey); err != nil {
+		panic(err.Error())
+	}
+	if parse == nil {
+		panic("parser function cannot be nil")
+	}
+	if toString == nil {
+		toString = DefaultToString
+	}
+	return Entry{
+		ctxKey:        ctxKey,
+		stringKey:     stringKey,
+		parseValue:    parse,
+		valueToString: toString,
+	}
 }
 
 // CtxKey returns the context key.
@@ -32,13 +67,76 @@ func (e Entry) StringKey() string {
 	return e.stringKey
 }
 
+// WithSensitive returns a copy of the Entry, marked as holding sensitive
+// values or not. Sensitive values are kept out of logs. They are propagated
+// like other values: sent to peers, kept by Detach and included in snapshots
+// and environment variables, unless skipped with WithoutSensitive.
+func (e Entry) WithSensitive(sensitive bool) Entry {
+	e.sensitive = sensitive
+	return e
+}
+
+// Sensitive reports whether the Entry holds sensitive values.
+func (e Entry) Sensitive() bool {
+	return e.sensitive
+}
+
+// WithMaxSize returns a copy of the Entry with a maximum size for marshalled
+// values, in bytes. Larger values are dropped and reported. Zero means no
+// maximum.
+func (e Entry) WithMaxSize(n int) Entry {
+	e.maxSize = n
+	return e
+}
+
+// MaxSize returns the maximum size for marshalled values.
+func (e Entry) MaxSize() int {
+	return e.maxSize
+}
+
+// CheckSize returns an error wrapping ErrValueTooLarge if the total size of
+// the marshalled values exceeds the maximum size.
+func (e Entry) CheckSize(vs ...string) error {
+	if e.maxSize <= 0 {
+		return nil
+	}
+	n := 0
+	for _, v := range vs {
+		n += len(v)
+	}
+	if n > e.maxSize {
+		return fmt.Errorf("value for key %q has %d bytes, maximum is %d: %w", e.stringKey, n, e.maxSize, ErrValueTooLarge)
+	}
+	return nil
+}
+
 // Unmarshal unmarshalls a value into 'a'. Returns an error if 'a' is not a
-// pointer.
+// pointer or the value exceeds the maximum size.
 func (e Entry) Unmarshal(s string, a any) error {
+	return e.unmarshal(s, a, true)
+}
+
+// unmarshal works as Unmarshal. Unless escaped, the value is not unescaped, as
+// in baggage, which has its own encoding.
+func (e Entry) unmarshal(s string, a any, escaped bool) error {
+	if err := e.CheckSize(s); err != nil {
+		return err
+	}
+	if escaped && e.toStrings == nil {
+		var err error
+		if s, err = unescape(s); err != nil {
+			return err
+		}
+	}
 	x, err := e.parseValue(s)
 	if err != nil {
 		return err
 	}
+	return assign(x, a)
+}
+
+// assign assigns x to the value 'a' points to.
+func assign(x, a any) error {
 	vp := reflect.ValueOf(a)
 	if vp.Type().Kind() != reflect.Pointer {
 		return fmt.Errorf("a must be a pointer")
@@ -52,16 +150,39 @@ func (e Entry) Unmarshal(s string, a any) error {
 	return nil
 }
 
-// Marshal marshals a value into a string.
+// Marshal marshals a value into a string. If the result is not safe to use
+// as a header or metadata value, it is base64url-encoded and marked as such.
+// Unmarshal reverses this.
 func (e Entry) Marshal(a any) string {
-	return e.valueToString(a)
+	return e.marshal(a, true)
 }
 
+// marshal works as Marshal, but only escapes the result if escaped is true.
+func (e Entry) marshal(a any, escaped bool) string {
+	s := e.valueToString(a)
+	if !escaped || e.toStrings != nil {
+		return s
+	}
+	return escape(s)
+}
+
+// A Config describes a Propagator.
 type Config struct {
 	HTTPHeaderPrefix   string
 	GrpcMetadataPrefix string
+	EnvPrefix          string
 	Entries            []Entry
 	NoDeadline         bool
+	DeadlineMode       DeadlineMode
+	DeadlineMargin     time.Duration
+	DeadlineInbound    InboundPolicy
+	DeadlineBounds     DeadlineBounds
+	FailFast           bool
+	FailFastThreshold  time.Duration
+	Reservation        Reservation
+	Strict             bool
+	OnError            ErrorFunc
+	Logger             *slog.Logger
 	Log                LogFunc
 }
 
@@ -69,123 +190,280 @@ type Config struct {
 // keys.
 const DefaultHeaderPrefix = "X-Go-Context-"
 
-var config = Config{
-	HTTPHeaderPrefix:   DefaultHeaderPrefix,
-	GrpcMetadataPrefix: DefaultHeaderPrefix,
-	Log:                log.Printf,
+func defaultConfig() Config {
+	return Config{
+		HTTPHeaderPrefix:   DefaultHeaderPrefix,
+		GrpcMetadataPrefix: DefaultHeaderPrefix,
+		EnvPrefix:          DefaultEnvPrefix,
+		Logger:             slog.New(defaultHandler{}),
+		Log:                log.Printf,
+	}
+}
+
+// A Propagator propagates the context values described by its Entries (and
+// the standard Go context deadline) over the network.
+type Propagator struct {
+	config  Config
+	entries registry
+}
+
+// NewPropagator creates a Propagator from the configuration. Empty prefixes
+// are replaced by DefaultHeaderPrefix (or DefaultEnvPrefix). Without a
+// structured logger and log function, logging is disabled. When multiple
+// entries share a context key, the last one is kept. A Propagator is safe for
+// concurrent use, entries can be added at any time.
+func NewPropagator(cfg Config) *Propagator {
+	p := &Propagator{config: cfg}
+	p.config.Entries = nil
+	if p.config.HTTPHeaderPrefix == "" {
+		p.config.HTTPHeaderPrefix = DefaultHeaderPrefix
+	}
+	if p.config.GrpcMetadataPrefix == "" {
+		p.config.GrpcMetadataPrefix = DefaultHeaderPrefix
+	}
+	if p.config.EnvPrefix == "" {
+		p.config.EnvPrefix = DefaultEnvPrefix
+	}
+	for _, e := range cfg.Entries {
+		p.Add(e)
+	}
+	return p
+}
+
+// HTTPHeaderPrefix returns the prefix for HTTP headers.
+func (p *Propagator) HTTPHeaderPrefix() string {
+	return p.config.HTTPHeaderPrefix
+}
+
+// GRPCMetadataPrefix returns the prefix for gRPC metadata keys.
+func (p *Propagator) GRPCMetadataPrefix() string {
+	return p.config.GrpcMetadataPrefix
+}
+
+// Entries returns the entries of the Propagator. The returned slice must not
+// be modified.
+func (p *Propagator) Entries() []Entry {
+	return p.entries.load()
+}
+
+// Log logs a message. It uses the structured logger (at info level) if one is
+// configured, the log function otherwise.
+func (p *Propagator) Log(format string, as ...any) {
+	if p.config.Logger != nil {
+		p.config.Logger.Info(fmt.Sprintf(format, as...))
+		return
+	}
+	if p.config.Log == nil {
+		return
+	}
+	p.config.Log(format, as...)
+}
+
+// Add adds an Entry. An Entry with the same context key is replaced.
+func (p *Propagator) Add(e Entry) {
+	p.entries.add(e)
+}
+
+var std = NewPropagator(defaultConfig())
+
+// Default returns the Propagator configured by the package-level functions.
+func Default() *Propagator {
+	return std
 }
 
 // Reset resets the configuration to its default state. It is mainly intended
 // for unit tests. Normal code should have no reason to call this function.
 func Reset() {
-	config = Config{
-		HTTPHeaderPrefix:   DefaultHeaderPrefix,
-		GrpcMetadataPrefix: DefaultHeaderPrefix,
-		Log:                log.Printf,
-	}
+	std.config = defaultConfig()
+	std.entries.reset()
 }
 
 // SetPrefixes sets the same header/metadata prefix for both HTTP/gRPC.
 func SetPrefixes(prefix string) {
-	config.HTTPHeaderPrefix = prefix
-	config.GrpcMetadataPrefix = prefix
+	std.config.HTTPHeaderPrefix = prefix
+	std.config.GrpcMetadataPrefix = prefix
 }
 
 // HTTPHeaderPrefix returns the prefix for HTTP headers.
 func HTTPHeaderPrefix() string {
-	return config.HTTPHeaderPrefix
+	return std.HTTPHeaderPrefix()
 }
 
 // SetHTTPHeaderPrefix sets the prefix for HTTP headers.
 func SetHTTPHeaderPrefix(prefix string) {
-	config.HTTPHeaderPrefix = prefix
+	std.config.HTTPHeaderPrefix = prefix
 }
 
 // GRPCMetadataPrefix returns the prefix for gRPC metadata keys.
 func GRPCMetadataPrefix() string {
-	return config.GrpcMetadataPrefix
+	return std.GRPCMetadataPrefix()
 }
 
 // SetGRPCMetadataPrefix sets the prefix for gRPC metadata keys.
 func SetGRPCMetadataPrefix(prefix string) {
-	config.GrpcMetadataPrefix = prefix
+	std.config.GrpcMetadataPrefix = prefix
 }
 
-// NoStandardDeadLine will disable propagation of the standard Go context
-// deadline. By default, it is enabled.
-func NoStandardDeadLine() {
-	config.NoDeadline = true
+type LogFunc func(format string, as ...any)
+
+// SetLogger sets the log function, replacing the structured logger. Setting
+// it to nil will disable logging.
+func SetLogger(logger LogFunc) {
+	std.config.Logger = nil
+	std.config.Log = logger
 }
 
-var deadline = timeEntry(nil, "Deadline")
+// SetStructuredLogger sets the structured logger. By default, the logger
+// returned by slog.Default is used. Setting it to nil will make the log
+// function be used instead.
+func SetStructuredLogger(logger *slog.Logger) {
+	std.config.Logger = logger
+}
 
-// Deadline returns the Entry to be used for propagating the standard Go
-// context.
-func Deadline() (Entry, bool) {
-	if config.NoDeadline {
-		return Entry{}, false
-	}
-	return deadline, true
+// defaultHandler forwards to the handler of the logger returned by
+// slog.Default at the moment of logging.
+type defaultHandler struct{}
+
+func (defaultHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return slog.Default().Handler().Enabled(ctx, l)
 }
 
-type LogFunc func(format string, as ...any)
+func (defaultHandler) Handle(ctx context.Context, r slog.Record) error {
+	return slog.Default().Handler().Handle(ctx, r)
+}
 
-// SetLogger sets the log function. Setting it to nil will disable logging.
-func SetLogger(logger LogFunc) {
-	config.Log = logger
+func (defaultHandler) WithAttrs(as []slog.Attr) slog.Handler {
+	return slog.Default().Handler().WithAttrs(as)
+}
+
+func (defaultHandler) WithGroup(name string) slog.Handler {
+	return slog.Default().Handler().WithGroup(name)
 }
 
 // Log logs a message.
 func Log(format string, as ...any) {
-	if config.Log == nil {
-		return
-	}
-	config.Log(format, as...)
+	std.Log(format, as...)
 }
 
+// Entries returns the registered entries. The returned slice must not be
+// modified.
 func Entries() []Entry {
-	return config.Entries
+	return std.Entries()
+}
+
+// Add adds an Entry. An Entry with the same context key is replaced.
+func Add(e Entry) {
+	std.Add(e)
+}
+
+// StringEntry creates an Entry for a string context value.
+func StringEntry(ctxKey any, stringKey string) Entry {
+	return NewEntry(ctxKey, stringKey, func(s string) (any, error) {
+		return s, nil
+	}, nil)
 }
 
 // String adds an Entry for a string context value.
 func String(ctxKey any, stringKey string) {
-	generic(ctxKey, stringKey, func(s string) (any, error) {
-		return s, nil
-	})
+	Add(StringEntry(ctxKey, stringKey))
 }
 
-// Int adds an Entry for an int context value.
-func Int(ctxKey any, stringKey string) {
-	generic(ctxKey, stringKey, func(s string) (any, error) {
+// IntEntry creates an Entry for an int context value.
+func IntEntry(ctxKey any, stringKey string) Entry {
+	return NewEntry(ctxKey, stringKey, func(s string) (any, error) {
 		i, err := strconv.Atoi(s)
 		return i, err
-	})
+	}, nil)
 }
 
-// Int32 adds an Entry for an int32 context value.
-func Int32(ctxKey any, stringKey string) {
-	generic(ctxKey, stringKey, func(s string) (any, error) {
+// Int adds an Entry for an int context value.
+func Int(ctxKey any, stringKey string) {
+	Add(IntEntry(ctxKey, stringKey))
+}
+
+// Int32Entry creates an Entry for an int32 context value.
+func Int32Entry(ctxKey any, stringKey string) Entry {
+	return NewEntry(ctxKey, stringKey, func(s string) (any, error) {
 		i, err := strconv.ParseInt(s, 10, 32)
 		return int32(i), err //nolint:gosec
-	})
+	}, nil)
+}
+
+// Int32 adds an Entry for an int32 context value.
+func Int32(ctxKey any, stringKey string) {
+	Add(Int32Entry(ctxKey, stringKey))
+}
+
+// Int64Entry creates an Entry for an int64 context value.
+func Int64Entry(ctxKey any, stringKey string) Entry {
+	return NewEntry(ctxKey, stringKey, func(s string) (any, error) {
+		i, err := strconv.ParseInt(s, 10, 64)
+		return i, err
+	}, nil)
 }
 
 // Int64 adds an Entry for an int64 context value.
 func Int64(ctxKey any, stringKey string) {
-	generic(ctxKey, stringKey, func(s string) (any, error) {
-		i, err := strconv.ParseInt(s, 10, 64)
-		return int32(i), err
-	})
+	Add(Int64Entry(ctxKey, stringKey))
+}
+
+// Uint64Entry creates an Entry for a uint64 context value.
+func Uint64Entry(ctxKey any, stringKey string) Entry {
+	return NewEntry(ctxKey, stringKey, func(s string) (any, error) {
+		i, err := strconv.ParseUint(s, 10, 64)
+		return i, err
+	}, nil)
+}
+
+// Uint64 adds an Entry for a uint64 context value.
+func Uint64(ctxKey any, stringKey string) {
+	Add(Uint64Entry(ctxKey, stringKey))
+}
+
+// Float64Entry creates an Entry for a float64 context value.
+func Float64Entry(ctxKey any, stringKey string) Entry {
+	return NewEntry(ctxKey, stringKey, func(s string) (any, error) {
+		f, err := strconv.ParseFloat(s, 64)
+		return f, err
+	}, nil)
+}
+
+// Float64 adds an Entry for a float64 context value.
+func Float64(ctxKey any, stringKey string) {
+	Add(Float64Entry(ctxKey, stringKey))
+}
+
+// BoolEntry creates an Entry for a bool context value.
+func BoolEntry(ctxKey any, stringKey string) Entry {
+	return NewEntry(ctxKey, stringKey, func(s string) (any, error) {
+		b, err := strconv.ParseBool(s)
+		return b, err
+	}, nil)
+}
+
+// Bool adds an Entry for a bool context value.
+func Bool(ctxKey any, stringKey string) {
+	Add(BoolEntry(ctxKey, stringKey))
+}
+
+// DurationEntry creates an Entry for a time.Duration context value.
+func DurationEntry(ctxKey any, stringKey string) Entry {
+	return NewEntry(ctxKey, stringKey, func(s string) (any, error) {
+		d, err := time.ParseDuration(s)
+		return d, err
+	}, nil)
 }
 
-func generic(ctxKey any, stringKey string, parse func(s string) (any, error)) {
-	Set(ctxKey, stringKey, parse, nil)
+// Duration adds an Entry for a time.Duration context value.
+func Duration(ctxKey any, stringKey string) {
+	Add(DurationEntry(ctxKey, stringKey))
 }
 
 // TimeFormat used for time.Time context values.
 var TimeFormat = time.RFC3339Nano
 
-func timeEntry(ctxKey any, stringKey string) Entry {
+// TimeEntry creates an Entry for a time.Time context value.
+func TimeEntry(ctxKey any, stringKey string) Entry {
 	parse := func(s string) (any, error) {
 		return time.Parse(TimeFormat, s)
 	}
@@ -196,45 +474,50 @@ func timeEntry(ctxKey any, stringKey string) Entry {
 		}
 		return t.Format(TimeFormat)
 	}
-	return Entry{
-		ctxKey:        ctxKey,
-		stringKey:     stringKey,
-		parseValue:    parse,
-		valueToString: toString,
-	}
+	return NewEntry(ctxKey, stringKey, parse, toString)
 }
 
 // Time adds an Entry for a time.Time context value.
 func Time(ctxKey any, stringKey string) {
-	set(timeEntry(ctxKey, stringKey))
+	Add(TimeEntry(ctxKey, stringKey))
 }
 
-// Set adds an Entry with the given parameters. The parser function is
-// required. If the stringer function is not provided, DefaultToString will be
-// used.
-func Set(ctxKey any, stringKey string, parse ParseFunc, toString StringFunc) {
-	if parse == nil {
-		panic("parser function cannot be nil")
+// TextEntry creates an Entry for a context value of type T, which is
+// marshalled using its encoding.TextMarshaler and encoding.TextUnmarshaler
+// implementations. Examples are netip.Addr and most UUID types.
+func TextEntry[T encoding.TextMarshaler, PT interface {
+	*T
+	encoding.TextUnmarshaler
+}](ctxKey any, stringKey string) Entry {
+	parse := func(s string) (T, error) {
+		var v T
+		err := PT(&v).UnmarshalText([]byte(s))
+		return v, err
 	}
-	if toString == nil {
-		toString = DefaultToString
+	format := func(v T) string {
+		bs, err := v.MarshalText()
+		if err != nil {
+			return ""
+		}
+		return string(bs)
 	}
-	set(Entry{
-		ctxKey:        ctxKey,
-		stringKey:     stringKey,
-		parseValue:    parse,
-		valueToString: toString,
-	})
+	return EntryOf(ctxKey, stringKey, parse, format)
 }
 
-func set(e Entry) {
-	for i := range config.Entries {
-		if config.Entries[i].CtxKey() == e.CtxKey() {
-			config.Entries[i] = e
-			return
-		}
-	}
-	config.Entries = append(config.Entries, e)
+// Text adds an Entry for a context value of type T, which is marshalled using
+// its encoding.TextMarshaler and encoding.TextUnmarshaler implementations.
+func Text[T encoding.TextMarshaler, PT interface {
+	*T
+	encoding.TextUnmarshaler
+}](ctxKey any, stringKey string) {
+	Add(TextEntry[T, PT](ctxKey, stringKey))
+}
+
+// Set adds an Entry with the given parameters. The parser function is
+// required. If the stringer function is not provided, DefaultToString will be
+// used.
+func Set(ctxKey any, stringKey string, parse ParseFunc, toString StringFunc) {
+	Add(NewEntry(ctxKey, stringKey, parse, toString))
 }
 
 // DefaultToString is a convenience wrapper around fmt.Sprintf.
@@ -0,0 +1,110 @@
+package netcontext_test
+
+import (
+	"math"
+	"net/netip"
+	"reflect"
+	"testing"
+	"testing/quick"
+	"time"
+
+	"github.com/HayoVanLoon/go-netcontext"
+)
+
+// roundTrip marshals a value and unmarshals the result.
+func roundTrip(t *testing.T, e netcontext.Entry, v any) any {
+	t.Helper()
+	got, err := marshalRoundTrip(e, v)
+	if err != nil {
+		t.Fatalf("error unmarshalling %v: %v", v, err)
+	}
+	return got
+}
+
+func marshalRoundTrip(e netcontext.Entry, v any) (any, error) {
+	var got any
+	err := e.Unmarshal(e.Marshal(v), &got)
+	return got, err
+}
+
+// roundTrips reports whether a value survives marshalling and unmarshalling.
+func roundTrips(e netcontext.Entry, v any) bool {
+	got, err := marshalRoundTrip(e, v)
+	return err == nil && got == v
+}
+
+func TestEntry_roundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		entry netcontext.Entry
+		value any
+	}{
+		{"bool true", netcontext.BoolEntry(ctxKey("b"), "b"), true},
+		{"bool false", netcontext.BoolEntry(ctxKey("b"), "b"), false},
+		{"float64", netcontext.Float64Entry(ctxKey("f"), "f"), 3.14},
+		{"float64 small", netcontext.Float64Entry(ctxKey("f"), "f"), math.SmallestNonzeroFloat64},
+		{"float64 max", netcontext.Float64Entry(ctxKey("f"), "f"), math.MaxFloat64},
+		{"float64 +Inf", netcontext.Float64Entry(ctxKey("f"), "f"), math.Inf(1)},
+		{"float64 -Inf", netcontext.Float64Entry(ctxKey("f"), "f"), math.Inf(-1)},
+		{"float64 -0", netcontext.Float64Entry(ctxKey("f"), "f"), math.Copysign(0, -1)},
+		{"uint64 zero", netcontext.Uint64Entry(ctxKey("u"), "u"), uint64(0)},
+		{"uint64 max", netcontext.Uint64Entry(ctxKey("u"), "u"), uint64(math.MaxUint64)},
+		{"int64 min", netcontext.Int64Entry(ctxKey("i"), "i"), int64(math.MinInt64)},
+		{"duration", netcontext.DurationEntry(ctxKey("d"), "d"), 1500 * time.Millisecond},
+		{"duration negative", netcontext.DurationEntry(ctxKey("d"), "d"), -90 * time.Second},
+		{"duration min", netcontext.DurationEntry(ctxKey("d"), "d"), time.Duration(math.MinInt64)},
+		{"duration max", netcontext.DurationEntry(ctxKey("d"), "d"), time.Duration(math.MaxInt64)},
+		{"addr v4", netcontext.TextEntry[netip.Addr](ctxKey("a"), "a"), netip.MustParseAddr("192.0.2.1")},
+		{"addr v6", netcontext.TextEntry[netip.Addr](ctxKey("a"), "a"), netip.MustParseAddr("2001:db8::1")},
+		{"addr v6 zone", netcontext.TextEntry[netip.Addr](ctxKey("a"), "a"), netip.MustParseAddr("fe80::1%eth0")},
+		{"addr v4-mapped", netcontext.TextEntry[netip.Addr](ctxKey("a"), "a"), netip.MustParseAddr("::ffff:192.0.2.1")},
+		{"addr zero", netcontext.TextEntry[netip.Addr](ctxKey("a"), "a"), netip.Addr{}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			got := roundTrip(t, tc.entry, tc.value)
+			if !reflect.DeepEqual(got, tc.value) {
+				t.Errorf("expected %#v, got %#v", tc.value, got)
+			}
+			if f, ok := tc.value.(float64); ok && math.Signbit(f) != math.Signbit(got.(float64)) {
+				t.Errorf("expected sign of %v to be kept, got %v", f, got)
+			}
+		})
+	}
+}
+
+func TestFloat64Entry_NaN(t *testing.T) {
+	got := roundTrip(t, netcontext.Float64Entry(ctxKey("f"), "f"), math.NaN())
+	if f, ok := got.(float64); !ok || !math.IsNaN(f) {
+		t.Errorf("expected NaN, got %#v", got)
+	}
+}
+
+func TestEntry_roundTripQuick(t *testing.T) {
+	for name, f := range map[string]any{
+		"bool": func(v bool) bool {
+			return roundTrips(netcontext.BoolEntry(ctxKey("b"), "b"), v)
+		},
+		"float64": func(v float64) bool {
+			return roundTrips(netcontext.Float64Entry(ctxKey("f"), "f"), v)
+		},
+		"uint64": func(v uint64) bool {
+			return roundTrips(netcontext.Uint64Entry(ctxKey("u"), "u"), v)
+		},
+		"duration": func(v int64) bool {
+			return roundTrips(netcontext.DurationEntry(ctxKey("d"), "d"), time.Duration(v))
+		},
+		"addr": func(bs [16]byte, v4 bool) bool {
+			a := netip.AddrFrom16(bs)
+			if v4 {
+				a = a.Unmap()
+			}
+			return roundTrips(netcontext.TextEntry[netip.Addr](ctxKey("a"), "a"), a)
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := quick.Check(f, nil); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
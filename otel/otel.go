@@ -0,0 +1,91 @@
+package otel
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/HayoVanLoon/go-netcontext"
+)
+
+// Propagator returns a propagation.TextMapPropagator for the registered
+// entries and the deadline. It can be combined with other propagators using
+// propagation.NewCompositeTextMapPropagator.
+func Propagator() propagation.TextMapPropagator {
+	return NewPropagator(netcontext.Default())
+}
+
+// NewPropagator works as Propagator, but uses the given Propagator. The keys
+// are the string keys of the entries, prefixed with the HTTP header prefix.
+func NewPropagator(p *netcontext.Propagator) propagation.TextMapPropagator {
+	return propagator{p: p}
+}
+
+type propagator struct {
+	p *netcontext.Propagator
+}
+
+func (x propagator) config() netcontext.CarrierConfig {
+	return netcontext.CarrierConfig{Prefix: x.p.HTTPHeaderPrefix()}
+}
+
+// Inject sets the context values and deadline in the carrier. Entries that
+// are not to be sent are skipped, values that are too large are reported and
+// skipped.
+func (x propagator) Inject(ctx context.Context, carrier propagation.TextMapCarrier) {
+	x.p.Inject(ctx, textMapCarrier{carrier}, x.config())
+}
+
+// Extract returns a context with the values found in the carrier. The carrier
+// is considered to come from a trusted peer. If a deadline is found, it is set
+// on the returned context; its resources are released once the context is
+// done. Values that could not be parsed are reported and skipped.
+func (x propagator) Extract(ctx context.Context, carrier propagation.TextMapCarrier) context.Context {
+	ctx, cancel, _ := x.p.Extract(ctx, textMapCarrier{carrier}, x.config(), true)
+	if cancel != nil {
+		context.AfterFunc(ctx, cancel)
+	}
+	return ctx
+}
+
+// Fields returns the keys the propagator sets.
+func (x propagator) Fields() []string {
+	cfg := x.config()
+	var fs []string
+	for _, e := range x.p.Entries() {
+		if e.Policy().Sends() {
+			fs = append(fs, cfg.Key(e))
+		}
+	}
+	if e, ok := x.p.Deadline(); ok {
+		fs = append(fs, cfg.Key(e))
+	}
+	return fs
+}
+
+// A textMapCarrier adapts a propagation.TextMapCarrier to a
+// netcontext.Carrier. Multiple values are joined with commas.
+type textMapCarrier struct {
+	c propagation.TextMapCarrier
+}
+
+func (c textMapCarrier) Get(key string) []string {
+	if v := c.c.Get(key); v != "" {
+		return []string{v}
+	}
+	return nil
+}
+
+// Set sets the values for a key. Since keys cannot be removed, setting no
+// values does nothing.
+func (c textMapCarrier) Set(key string, values ...string) {
+	if len(values) == 0 {
+		return
+	}
+	c.c.Set(key, strings.Join(values, ","))
+}
+
+func (c textMapCarrier) Keys() []string {
+	return c.c.Keys()
+}
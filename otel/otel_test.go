@@ -0,0 +1,112 @@
+package otel_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/baggage"
+	"go.opentelemetry.io/otel/propagation"
+
+	"github.com/HayoVanLoon/go-netcontext"
+	"github.com/HayoVanLoon/go-netcontext/otel"
+)
+
+type ctxKey string
+
+func newPropagator() *netcontext.Propagator {
+	return netcontext.NewPropagator(netcontext.Config{
+		HTTPHeaderPrefix: "x-test-",
+		Entries: []netcontext.Entry{
+			netcontext.StringEntry(ctxKey("tenant"), "tenant"),
+			netcontext.IntEntry(ctxKey("n"), "n"),
+			netcontext.StringEntry(ctxKey("local"), "local").WithPolicy(netcontext.Policy{Outbound: netcontext.SendNone}),
+		},
+	})
+}
+
+func TestPropagator(t *testing.T) {
+	x := otel.NewPropagator(newPropagator())
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	want, _ := ctx.Deadline()
+	ctx = context.WithValue(ctx, ctxKey("tenant"), "acme")
+	ctx = context.WithValue(ctx, ctxKey("n"), 7)
+	ctx = context.WithValue(ctx, ctxKey("local"), "secret")
+
+	c := propagation.MapCarrier{}
+	x.Inject(ctx, c)
+	if got := c.Get("x-test-tenant"); got != "acme" {
+		t.Errorf("expected tenant %q, got %q", "acme", got)
+	}
+	if got := c.Get("x-test-local"); got != "" {
+		t.Errorf("expected entry with SendNone policy not to be injected, got %q", got)
+	}
+
+	out := x.Extract(context.Background(), c)
+	for k, v := range map[ctxKey]any{"tenant": "acme", "n": 7, "local": nil} {
+		if got := out.Value(k); got != v {
+			t.Errorf("expected %v for %q, got %v", v, k, got)
+		}
+	}
+	got, ok := out.Deadline()
+	if !ok {
+		t.Fatal("expected deadline")
+	}
+	if d := got.Sub(want).Abs(); d > time.Millisecond {
+		t.Errorf("expected deadline %v, got %v", want, got)
+	}
+}
+
+func TestPropagator_Extract(t *testing.T) {
+	x := otel.NewPropagator(newPropagator())
+	c := propagation.MapCarrier{"x-test-tenant": "acme", "x-test-n": "seven"}
+	out := x.Extract(context.Background(), c)
+	if got := out.Value(ctxKey("tenant")); got != "acme" {
+		t.Errorf("expected tenant %q, got %v", "acme", got)
+	}
+	if got := out.Value(ctxKey("n")); got != nil {
+		t.Errorf("expected invalid value to be skipped, got %v", got)
+	}
+	if _, ok := out.Deadline(); ok {
+		t.Error("expected no deadline")
+	}
+}
+
+func TestPropagator_Fields(t *testing.T) {
+	x := otel.NewPropagator(newPropagator())
+	want := []string{"x-test-tenant", "x-test-n", "x-test-Deadline"}
+	if got := x.Fields(); !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestPropagator_composite(t *testing.T) {
+	x := propagation.NewCompositeTextMapPropagator(propagation.Baggage{}, otel.NewPropagator(newPropagator()))
+	m, err := baggage.NewMember("user", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := baggage.New(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := baggage.ContextWithBaggage(context.Background(), b)
+	ctx = context.WithValue(ctx, ctxKey("tenant"), "acme")
+
+	c := propagation.MapCarrier{}
+	x.Inject(ctx, c)
+	out := x.Extract(context.Background(), c)
+	if got := baggage.FromContext(out).Member("user").Value(); got != "alice" {
+		t.Errorf("expected baggage member %q, got %q", "alice", got)
+	}
+	if got := out.Value(ctxKey("tenant")); got != "acme" {
+		t.Errorf("expected tenant %q, got %v", "acme", got)
+	}
+	for _, f := range []string{"baggage", "x-test-tenant"} {
+		if !slices.Contains(x.Fields(), f) {
+			t.Errorf("expected field %q in %v", f, x.Fields())
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package netcontext
+
+// An InboundPolicy determines from which peers incoming values are accepted.
+type InboundPolicy int
+
+const (
+	// AcceptAll accepts values from all peers. This is the default.
+	AcceptAll InboundPolicy = iota
+	// OnlyFromTrusted only accepts values from peers deemed trustworthy by
+	// the trust function of the server wrapper or interceptor. Without a
+	// trust function, no peer is trusted.
+	OnlyFromTrusted
+	// RejectAll never accepts incoming values.
+	RejectAll
+)
+
+// An OutboundPolicy determines whether values are sent to peers.
+type OutboundPolicy int
+
+const (
+	// SendAll sends values to all peers. This is the default.
+	SendAll OutboundPolicy = iota
+	// SendNone never sends values.
+	SendNone
+)
+
+// A Policy describes in which directions and from which peers an Entry is
+// propagated.
+type Policy struct {
+	Inbound  InboundPolicy
+	Outbound OutboundPolicy
+}
+
+// WithPolicy returns a copy of the Entry with the given Policy.
+func (e Entry) WithPolicy(p Policy) Entry {
+	e.policy = p
+	return e
+}
+
+// Policy returns the Policy of the Entry.
+func (e Entry) Policy() Policy {
+	return e.policy
+}
+
+// Accepts reports whether an incoming value is accepted from a peer.
+func (p Policy) Accepts(trusted bool) bool {
+	switch p.Inbound {
+	case AcceptAll:
+		return true
+	case OnlyFromTrusted:
+		return trusted
+	default:
+		return false
+	}
+}
+
+// Sends reports whether a value is sent to peers.
+func (p Policy) Sends() bool {
+	return p.Outbound == SendAll
+}
+
+// AcceptsDeadline reports whether an incoming deadline is accepted from a
+// peer.
+func (p *Propagator) AcceptsDeadline(trusted bool) bool {
+	return Policy{Inbound: p.config.DeadlineInbound}.Accepts(trusted)
+}
+
+// SetDeadlineInbound sets the InboundPolicy for the standard Go context
+// deadline.
+func SetDeadlineInbound(policy InboundPolicy) {
+	std.config.DeadlineInbound = policy
+}
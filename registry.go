@@ -0,0 +1,49 @@
+package netcontext
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// A registry holds a set of entries. It is safe for concurrent use. Additions
+// replace the entry slice (copy-on-write), so lookups never need a lock.
+type registry struct {
+	mu      sync.Mutex
+	entries atomic.Pointer[[]Entry]
+}
+
+// load returns the current entries. The result must not be modified.
+func (r *registry) load() []Entry {
+	es := r.entries.Load()
+	if es == nil {
+		return nil
+	}
+	return *es
+}
+
+// add adds an Entry, replacing one with the same context key.
+func (r *registry) add(e Entry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := r.load()
+	es := make([]Entry, 0, len(old)+1)
+	found := false
+	for _, x := range old {
+		if x.CtxKey() == e.CtxKey() {
+			x = e
+			found = true
+		}
+		es = append(es, x)
+	}
+	if !found {
+		es = append(es, e)
+	}
+	r.entries.Store(&es)
+}
+
+// reset removes all entries.
+func (r *registry) reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries.Store(nil)
+}
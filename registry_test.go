@@ -0,0 +1,92 @@
+package netcontext_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+
+	"github.com/HayoVanLoon/go-netcontext"
+	ncgrpc "github.com/HayoVanLoon/go-netcontext/grpc"
+	nchttp "github.com/HayoVanLoon/go-netcontext/http"
+)
+
+type roundTripFunc func(r *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+type ctxKey string
+
+// TestRegistryConcurrency registers entries while they are being read. It is
+// meant to be run with the race detector.
+func TestRegistryConcurrency(t *testing.T) {
+	netcontext.Reset()
+	t.Cleanup(netcontext.Reset)
+	netcontext.String(ctxKey("k"), "k")
+
+	rt := nchttp.NewRoundTripper(netcontext.Default(), roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Request: r}, nil
+	}))
+	ctx := context.WithValue(context.Background(), ctxKey("k"), "v")
+
+	const writers, n = 4, 100
+	var wg sync.WaitGroup
+	for i := range writers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range n {
+				key := fmt.Sprintf("w%d-%d", i, j)
+				if j%2 == 0 {
+					netcontext.String(ctxKey(key), key)
+				} else {
+					netcontext.Add(netcontext.IntEntry(ctxKey(key), key))
+				}
+			}
+		}()
+	}
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for range n {
+			for _, e := range netcontext.Entries() {
+				_ = e.StringKey()
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range n {
+			r, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.com", nil)
+			if _, err := rt.RoundTrip(r); err != nil {
+				t.Error(err)
+				return
+			}
+			if got := r.Header.Get(netcontext.HTTPHeaderPrefix() + "k"); got != "v" {
+				t.Errorf("expected header value %q, got %q", "v", got)
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		md := metadata.Pairs(netcontext.GRPCMetadataPrefix()+"k", "v")
+		for range n {
+			ctx := ncgrpc.ExtractMetadata(metadata.NewIncomingContext(context.Background(), md))
+			if got := ctx.Value(ctxKey("k")); got != "v" {
+				t.Errorf("expected context value %q, got %v", "v", got)
+				return
+			}
+		}
+	}()
+	wg.Wait()
+
+	if got, want := len(netcontext.Entries()), 1+writers*n; got != want {
+		t.Errorf("expected %d entries, got %d", want, got)
+	}
+}
@@ -0,0 +1,83 @@
+package netcontext
+
+import (
+	"context"
+	"sync"
+)
+
+// A Response holds context values travelling back from server to client. The
+// values are stored by context key and need a registered Entry to be
+// propagated. It is safe for concurrent use.
+type Response struct {
+	mu     sync.Mutex
+	values map[any]any
+}
+
+// Set sets a value.
+func (r *Response) Set(ctxKey, v any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.values == nil {
+		r.values = map[any]any{}
+	}
+	r.values[ctxKey] = v
+}
+
+// Value returns a value, or nil if it is absent.
+func (r *Response) Value(ctxKey any) any {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.values[ctxKey]
+}
+
+// Len returns the number of values.
+func (r *Response) Len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.values)
+}
+
+type outgoingResponseKey struct{}
+
+type incomingResponseKey struct{}
+
+// WithOutgoingResponse returns a context holding a new Response for values
+// to be sent back to the caller. It is used by server wrappers and
+// interceptors.
+func WithOutgoingResponse(ctx context.Context) (context.Context, *Response) {
+	r := &Response{}
+	return context.WithValue(ctx, outgoingResponseKey{}, r), r
+}
+
+// OutgoingResponse returns the Response for values to be sent back to the
+// caller, or nil if there is none.
+func OutgoingResponse(ctx context.Context) *Response {
+	r, _ := ctx.Value(outgoingResponseKey{}).(*Response)
+	return r
+}
+
+// Respond marks a value to be sent back to the caller in the response headers
+// (or gRPC trailers). The value needs a registered Entry. Returns false if
+// the context does not come from a server wrapper or interceptor.
+func Respond(ctx context.Context, ctxKey, v any) bool {
+	r := OutgoingResponse(ctx)
+	if r == nil {
+		return false
+	}
+	r.Set(ctxKey, v)
+	return true
+}
+
+// WithIncomingResponse returns a context holding a new Response. Client
+// wrappers and interceptors store the values returned by the server in it.
+func WithIncomingResponse(ctx context.Context) (context.Context, *Response) {
+	r := &Response{}
+	return context.WithValue(ctx, incomingResponseKey{}, r), r
+}
+
+// IncomingResponse returns the Response for values returned by the server, or
+// nil if there is none.
+func IncomingResponse(ctx context.Context) *Response {
+	r, _ := ctx.Value(incomingResponseKey{}).(*Response)
+	return r
+}
@@ -0,0 +1,55 @@
+package slog
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/HayoVanLoon/go-netcontext"
+)
+
+// WrapHandler wraps a slog.Handler, adding an attribute for every value of the
+// registered entries found in the context of a record. The string keys of the
+// entries are used as attribute names. Values of sensitive entries are
+// omitted.
+func WrapHandler(h slog.Handler) slog.Handler {
+	return NewHandler(netcontext.Default(), h)
+}
+
+// NewHandler works as WrapHandler, but uses the entries of the given
+// Propagator.
+func NewHandler(p *netcontext.Propagator, h slog.Handler) slog.Handler {
+	return handler{p: p, h: h}
+}
+
+type handler struct {
+	p *netcontext.Propagator
+	h slog.Handler
+}
+
+func (h handler) Enabled(ctx context.Context, l slog.Level) bool {
+	return h.h.Enabled(ctx, l)
+}
+
+func (h handler) Handle(ctx context.Context, r slog.Record) error {
+	if ctx == nil {
+		return h.h.Handle(ctx, r)
+	}
+	r = r.Clone()
+	for _, e := range h.p.Entries() {
+		if e.Sensitive() {
+			continue
+		}
+		if v := ctx.Value(e.CtxKey()); v != nil {
+			r.AddAttrs(slog.Any(e.StringKey(), v))
+		}
+	}
+	return h.h.Handle(ctx, r)
+}
+
+func (h handler) WithAttrs(as []slog.Attr) slog.Handler {
+	return handler{p: h.p, h: h.h.WithAttrs(as)}
+}
+
+func (h handler) WithGroup(name string) slog.Handler {
+	return handler{p: h.p, h: h.h.WithGroup(name)}
+}
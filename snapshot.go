@@ -0,0 +1,127 @@
+package netcontext
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+)
+
+// A Snapshot holds the marshalled context values of the entries of a
+// Propagator, keyed by their string keys. It can be serialised (e.g. as
+// JSON) to carry the values across asynchronous boundaries, like job queues.
+type Snapshot struct {
+	Values map[string][]string `json:"values,omitempty"`
+	// Budget is the time that was left until the deadline when the snapshot
+	// was taken. It is nil if there was no deadline.
+	Budget *time.Duration `json:"budget,omitempty"`
+}
+
+// A CaptureOption configures which context values are captured in a Snapshot
+// or passed in environment variables.
+type CaptureOption func(*captureOptions)
+
+type captureOptions struct {
+	noSensitive bool
+	onlySent    bool
+}
+
+func newCaptureOptions(opts []CaptureOption) *captureOptions {
+	o := &captureOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// WithoutSensitive skips the values of sensitive entries, for instance when
+// the result is stored.
+func WithoutSensitive() CaptureOption {
+	return func(o *captureOptions) {
+		o.noSensitive = true
+	}
+}
+
+// OnlySent skips the values of entries that are not sent to peers.
+func OnlySent() CaptureOption {
+	return func(o *captureOptions) {
+		o.onlySent = true
+	}
+}
+
+// skip reports whether the values of the Entry are skipped.
+func (o *captureOptions) skip(e Entry) bool {
+	return o.noSensitive && e.Sensitive() || o.onlySent && !e.Policy().Sends()
+}
+
+// Snapshot takes a Snapshot of the values of the entries in the context. Unlike
+// outgoing requests, it includes the values of entries that are not sent to
+// peers. Sensitive values are included as well; use WithoutSensitive to skip
+// them.
+func (p *Propagator) Snapshot(ctx context.Context, opts ...CaptureOption) Snapshot {
+	o := newCaptureOptions(opts)
+	var s Snapshot
+	for _, e := range p.Entries() {
+		if o.skip(e) {
+			continue
+		}
+		v := ctx.Value(e.CtxKey())
+		if v == nil {
+			continue
+		}
+		if s.Values == nil {
+			s.Values = make(map[string][]string)
+		}
+		s.Values[e.StringKey()] = e.MarshalValues(v)
+	}
+	if t, ok := ctx.Deadline(); ok {
+		b := time.Until(t)
+		s.Budget = &b
+	}
+	return s
+}
+
+// Restore returns a context with the values in the Snapshot. Values that
+// cannot be parsed are reported as *ParseError and returned.
+func (p *Propagator) Restore(ctx context.Context, s Snapshot) (context.Context, error) {
+	var errs []error
+	for _, e := range p.Entries() {
+		vs := s.Values[e.StringKey()]
+		if len(vs) == 0 {
+			continue
+		}
+		var a any
+		if err := e.UnmarshalValues(vs, &a); err != nil {
+			err = &ParseError{Entry: e, Raw: strings.Join(vs, ","), Err: err}
+			p.Report(err)
+			errs = append(errs, err)
+			continue
+		}
+		ctx = context.WithValue(ctx, e.CtxKey(), a)
+	}
+	return ctx, errors.Join(errs...)
+}
+
+// TakeSnapshot takes a Snapshot of the values of the registered entries in the
+// context. It is not called Snapshot, as that is the name of the type.
+func TakeSnapshot(ctx context.Context, opts ...CaptureOption) Snapshot {
+	return std.Snapshot(ctx, opts...)
+}
+
+// Restore returns a context with the values in the Snapshot. It never sets a
+// deadline.
+func Restore(ctx context.Context, s Snapshot) context.Context {
+	ctx, _ = std.Restore(ctx, s)
+	return ctx
+}
+
+// RestoreWithDeadline works as Restore, but also sets a deadline if the
+// Snapshot has a budget, starting now. The cancellation function is nil if it
+// does not.
+func RestoreWithDeadline(ctx context.Context, s Snapshot) (context.Context, context.CancelFunc) {
+	ctx = Restore(ctx, s)
+	if s.Budget == nil {
+		return ctx, nil
+	}
+	return context.WithTimeout(ctx, *s.Budget)
+}